@@ -0,0 +1,293 @@
+package gorouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat is the line format of an access log.
+type AccessLogFormat int
+
+// Access log formats.
+const (
+	AccessLogCombined AccessLogFormat = iota // NCSA Combined Log Format
+	AccessLogCommon                          // NCSA Common Log Format
+	AccessLogJSON                            // One JSON object per line with the selected fields
+	AccessLogLogfmt                          // key=value pairs with the selected fields
+)
+
+// Access log field names, for AccessLogConfig.Fields.
+const (
+	LogFieldTime      = "time"
+	LogFieldMethod    = "method"
+	LogFieldPath      = "path"
+	LogFieldProto     = "proto"
+	LogFieldStatus    = "status"
+	LogFieldBytes     = "bytes"
+	LogFieldLatency   = "latency_ms"
+	LogFieldRemote    = "remote"
+	LogFieldUserAgent = "user_agent"
+	LogFieldReferer   = "referer"
+	LogFieldRoute     = "route"
+	LogFieldRequestID = "request_id"
+	LogFieldUser      = "user"
+	LogFieldSlow      = "slow"
+)
+
+// DefaultAccessLogFields are the fields of JSON and logfmt access logs when
+// AccessLogConfig leaves Fields unset.
+var DefaultAccessLogFields = []string{
+	LogFieldTime, LogFieldMethod, LogFieldPath, LogFieldProto, LogFieldStatus, LogFieldBytes, LogFieldLatency,
+	LogFieldRemote, LogFieldUserAgent, LogFieldReferer, LogFieldRoute, LogFieldRequestID, LogFieldUser, LogFieldSlow,
+}
+
+// AccessLogConfig represents configuration options for the AccessLog middleware.
+type AccessLogConfig struct {
+	Output        io.Writer                    // Where lines are written, e.g. a RotatingFile; defaults to os.Stdout
+	Format        AccessLogFormat              // Defaults to AccessLogCombined
+	Fields        []string                     // Fields of JSON and logfmt lines, in order; defaults to DefaultAccessLogFields
+	SampleRate    float64                      // Fraction of requests logged; zero logs all. Server errors and slow requests are always logged
+	SlowThreshold time.Duration                // Requests taking at least this long are always logged and marked slow; zero disables
+	Skip          func(req *http.Request) bool // Requests not to log, e.g. health checks
+}
+
+// AccessLogEntry describes a completed request.
+type AccessLogEntry struct {
+	Time      time.Time     // When the request started
+	Method    string        // Request method
+	Path      string        // Request URI as sent by the client, redacted
+	Proto     string        // Protocol, e.g. "HTTP/1.1"
+	Status    int           // Response status
+	Bytes     int64         // Response body bytes
+	Latency   time.Duration // Time to complete the request
+	Remote    string        // Client IP
+	UserAgent string        // User-Agent header
+	Referer   string        // Referer header, redacted
+	Route     string        // Matched route pattern
+	RequestID string        // ID set by the RequestID middleware
+	User      string        // User set with WithUser before the access log ran
+	Slow      bool          // Latency reached the slow threshold
+}
+
+// AccessLog returns a middleware writing a line for each request after it
+// completes. The request ID is found wherever the RequestID middleware runs,
+// but the user is only known if WithUser was called outside this middleware.
+func AccessLog(config AccessLogConfig) Middleware {
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if len(config.Fields) == 0 {
+		config.Fields = DefaultAccessLogFields
+	}
+	mu := new(sync.Mutex)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if config.Skip != nil && config.Skip(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			start := time.Now()
+			rw := WrapResponseWriter(w)
+			next.ServeHTTP(rw, req)
+
+			entry := newAccessLogEntry(rw, req, start)
+			entry.Slow = config.SlowThreshold > 0 && entry.Latency >= config.SlowThreshold
+			sampled := config.SampleRate <= 0 || config.SampleRate >= 1 || rand.Float64() < config.SampleRate
+			if !sampled && !entry.Slow && entry.Status < http.StatusInternalServerError {
+				return
+			}
+
+			buf := getBuffer()
+			defer putBuffer(buf)
+			config.Format.append(buf, entry, config.Fields)
+			buf.WriteByte('\n')
+			mu.Lock()
+			defer mu.Unlock()
+			if _, err := config.Output.Write(buf.Bytes()); err != nil {
+				LoggerFrom(req.Context()).Log(req.Context(), slog.LevelError, "Failed to write access log", "error", err)
+			}
+		})
+	}
+}
+
+func newAccessLogEntry(rw ResponseWriter, req *http.Request, start time.Time) *AccessLogEntry {
+	status := rw.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	requestID := RequestIDFromContext(req.Context())
+	if requestID == "" {
+		requestID = rw.Header().Get(RequestIDHeader)
+	}
+	path := req.RequestURI
+	if path == "" {
+		path = req.URL.RequestURI()
+	}
+	redaction := RedactionFrom(req.Context())
+	remote, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		remote = req.RemoteAddr
+	}
+	return &AccessLogEntry{
+		Time:      start,
+		Method:    req.Method,
+		Path:      redaction.URL(path),
+		Proto:     req.Proto,
+		Status:    status,
+		Bytes:     rw.BytesWritten(),
+		Latency:   time.Since(start),
+		Remote:    remote,
+		UserAgent: req.UserAgent(),
+		Referer:   redaction.URL(req.Referer()),
+		Route:     RoutePattern(req),
+		RequestID: requestID,
+		User:      UserFromContext(req.Context()),
+	}
+}
+
+// append writes the entry in format f.
+func (f AccessLogFormat) append(buf *bytes.Buffer, entry *AccessLogEntry, fields []string) {
+	switch f {
+	case AccessLogJSON:
+		appendJSONEntry(buf, entry, fields)
+	case AccessLogLogfmt:
+		appendLogfmtEntry(buf, entry, fields)
+	default:
+		appendCommonEntry(buf, entry, f == AccessLogCombined)
+	}
+}
+
+// appendCommonEntry writes the Common or Combined Log Format.
+func appendCommonEntry(buf *bytes.Buffer, entry *AccessLogEntry, combined bool) {
+	buf.WriteString(orDash(entry.Remote))
+	buf.WriteString(" - ")
+	buf.WriteString(orDash(entry.User))
+	buf.WriteString(entry.Time.Format(" [02/Jan/2006:15:04:05 -0700] "))
+	buf.WriteString(strconv.Quote(entry.Method + " " + entry.Path + " " + entry.Proto))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(entry.Status))
+	buf.WriteByte(' ')
+	if entry.Bytes > 0 {
+		buf.WriteString(strconv.FormatInt(entry.Bytes, 10))
+	} else {
+		buf.WriteByte('-')
+	}
+	if combined {
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.Quote(entry.Referer))
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.Quote(entry.UserAgent))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// fieldValue returns the value of a field; strings are returned as string.
+func (entry *AccessLogEntry) fieldValue(field string) (interface{}, bool) {
+	switch field {
+	case LogFieldTime:
+		return entry.Time.Format(time.RFC3339Nano), true
+	case LogFieldMethod:
+		return entry.Method, true
+	case LogFieldPath:
+		return entry.Path, true
+	case LogFieldProto:
+		return entry.Proto, true
+	case LogFieldStatus:
+		return entry.Status, true
+	case LogFieldBytes:
+		return entry.Bytes, true
+	case LogFieldLatency:
+		return float64(entry.Latency.Microseconds()) / 1000, true
+	case LogFieldRemote:
+		return entry.Remote, true
+	case LogFieldUserAgent:
+		return entry.UserAgent, true
+	case LogFieldReferer:
+		return entry.Referer, true
+	case LogFieldRoute:
+		return entry.Route, true
+	case LogFieldRequestID:
+		return entry.RequestID, true
+	case LogFieldUser:
+		return entry.User, true
+	case LogFieldSlow:
+		return entry.Slow, true
+	}
+	return nil, false
+}
+
+// appendJSONEntry writes the fields as a JSON object, omitting empty strings.
+func appendJSONEntry(buf *bytes.Buffer, entry *AccessLogEntry, fields []string) {
+	buf.WriteByte('{')
+	first := true
+	for _, field := range fields {
+		value, ok := entry.fieldValue(field)
+		if !ok || value == "" || value == false {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(field)
+		data, _ := json.Marshal(value)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+}
+
+// appendLogfmtEntry writes the fields as logfmt, omitting empty strings.
+func appendLogfmtEntry(buf *bytes.Buffer, entry *AccessLogEntry, fields []string) {
+	first := true
+	for _, field := range fields {
+		value, ok := entry.fieldValue(field)
+		if !ok || value == "" || value == false {
+			continue
+		}
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		buf.WriteString(field)
+		buf.WriteByte('=')
+		switch v := value.(type) {
+		case string:
+			buf.WriteString(logfmtValue(v))
+		case float64:
+			buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case int:
+			buf.WriteString(strconv.Itoa(v))
+		case int64:
+			buf.WriteString(strconv.FormatInt(v, 10))
+		case bool:
+			buf.WriteString(strconv.FormatBool(v))
+		}
+	}
+}
+
+// logfmtValue quotes s if it contains spaces, quotes, '=' or control characters.
+func logfmtValue(s string) string {
+	if strings.ContainsFunc(s, func(r rune) bool { return r <= ' ' || r == '"' || r == '=' || r == 0x7f }) {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package gorouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveAccessLog serves a request to /items/1 through a router with the
+// access log and returns what was logged.
+func serveAccessLog(t *testing.T, config AccessLogConfig, handler http.HandlerFunc) string {
+	t.Helper()
+	var buf bytes.Buffer
+	config.Output = &buf
+
+	router := NewRouter()
+	router.Use(AccessLog(config), RequestID)
+	router.AddRoute(http.MethodGet, "/items/:id", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1?full=true", nil)
+	req.RemoteAddr = "192.0.2.7:4321"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set(RequestIDHeader, "req-9")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	return buf.String()
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	line := serveAccessLog(t, AccessLogConfig{}, okHandler)
+	pattern := `^192\.0\.2\.7 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /items/1\?full=true HTTP/1\.1" 200 2 "https://example\.com/" "curl/8\.0"\n$`
+	if !regexp.MustCompile(pattern).MatchString(line) {
+		t.Errorf("Unexpected combined log line %q", line)
+	}
+
+	line = serveAccessLog(t, AccessLogConfig{Format: AccessLogCommon}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	if !strings.HasSuffix(line, `"GET /items/1?full=true HTTP/1.1" 204 -`+"\n") {
+		t.Errorf("Unexpected common log line %q", line)
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	line := serveAccessLog(t, AccessLogConfig{Format: AccessLogJSON}, okHandler)
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", line, err)
+	}
+	want := map[string]interface{}{
+		"method": "GET", "path": "/items/1?full=true", "status": 200.0, "bytes": 2.0, "remote": "192.0.2.7",
+		"user_agent": "curl/8.0", "route": "/items/:id", "request_id": "req-9",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Errorf("Expected a numeric latency, got %v", entry["latency_ms"])
+	}
+	if _, ok := entry["slow"]; ok {
+		t.Error("Expected fast requests not to be marked slow")
+	}
+}
+
+func TestAccessLogLogfmtFields(t *testing.T) {
+	line := serveAccessLog(t, AccessLogConfig{
+		Format: AccessLogLogfmt,
+		Fields: []string{LogFieldMethod, LogFieldRoute, LogFieldStatus, LogFieldUserAgent, LogFieldSlow},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("User-Agent", "ignored")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	if line != "method=GET route=/items/:id status=202 user_agent=curl/8.0\n" {
+		t.Errorf("Unexpected logfmt line %q", line)
+	}
+	if got := logfmtValue(`a "b"`); got != `"a \"b\""` {
+		t.Errorf("Expected quoting, got %s", got)
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	status := http.StatusOK
+	delay := time.Duration(0)
+	handler := AccessLog(AccessLogConfig{Output: &buf, Format: AccessLogLogfmt, Fields: []string{LogFieldStatus, LogFieldSlow}, SampleRate: 1e-9, SlowThreshold: 20 * time.Millisecond})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(status)
+		}))
+	serve := func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	serve()
+	if buf.Len() != 0 {
+		t.Errorf("Expected the request to be sampled out, got %q", buf.String())
+	}
+	status = http.StatusBadGateway
+	serve()
+	status, delay = http.StatusOK, 20*time.Millisecond
+	serve()
+	if buf.String() != "status=502\nstatus=200 slow=true\n" {
+		t.Errorf("Expected server errors and slow requests to always be logged, got %q", buf.String())
+	}
+}
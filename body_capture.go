@@ -0,0 +1,324 @@
+package gorouter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultBodyCaptureLimit is the number of bytes captured from each body
+// when BodyCaptureConfig leaves MaxBytes unset.
+const DefaultBodyCaptureLimit = 64 << 10
+
+// CapturedBody is a request or response body seen by BodyCapture.
+type CapturedBody struct {
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`      // Redacted body, up to the capture limit
+	Size        int64  `json:"size"`                // Bytes read or written in total
+	Truncated   bool   `json:"truncated,omitempty"` // Body is cut at the capture limit
+	Skipped     bool   `json:"skipped,omitempty"`   // Body is not textual and was not captured
+}
+
+// CapturedExchange is a request and its response captured by BodyCapture.
+type CapturedExchange struct {
+	ID             uint64        `json:"id"`
+	Time           time.Time     `json:"time"`
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	Route          string        `json:"route,omitempty"`
+	RequestID      string        `json:"request_id,omitempty"`
+	Status         int           `json:"status"`
+	Latency        time.Duration `json:"latency"`
+	RequestHeader  http.Header   `json:"request_header"`
+	Request        CapturedBody  `json:"request"`
+	ResponseHeader http.Header   `json:"response_header"`
+	Response       CapturedBody  `json:"response"`
+}
+
+// CaptureSink receives captured exchanges.
+type CaptureSink interface {
+	Capture(ctx context.Context, exchange *CapturedExchange)
+}
+
+// CaptureSinkFunc adapts a function to the CaptureSink interface.
+type CaptureSinkFunc func(ctx context.Context, exchange *CapturedExchange)
+
+// Capture calls f(ctx, exchange).
+func (f CaptureSinkFunc) Capture(ctx context.Context, exchange *CapturedExchange) {
+	f(ctx, exchange)
+}
+
+// LogCaptureSink returns a CaptureSink logging exchanges at debug level with
+// the request-scoped logger.
+func LogCaptureSink() CaptureSink {
+	return CaptureSinkFunc(func(ctx context.Context, exchange *CapturedExchange) {
+		LoggerFrom(ctx).Log(ctx, slog.LevelDebug, "Captured exchange",
+			"method", exchange.Method,
+			"url", exchange.URL,
+			"status", exchange.Status,
+			"request_body", exchange.Request.Body,
+			"response_body", exchange.Response.Body,
+		)
+	})
+}
+
+// BodyCaptureConfig represents configuration options for the BodyCapture middleware.
+type BodyCaptureConfig struct {
+	MaxBytes     int64                        // Bytes captured from each body; defaults to DefaultBodyCaptureLimit
+	ContentTypes []string                     // Media types captured, like "application/json" or "text/*"; defaults to textual types
+	Sink         CaptureSink                  // Defaults to LogCaptureSink
+	Skip         func(req *http.Request) bool // Requests not to capture
+}
+
+// BodyCapture returns a middleware capturing request and response bodies for
+// debugging. Bodies are teed as the handler reads and writes them, so only
+// the part of the request body the handler read is captured. Headers, the
+// URL and bodies are masked with the request's RedactionPolicy. WebSocket
+// upgrades are not captured.
+func BodyCapture(config BodyCaptureConfig) Middleware {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultBodyCaptureLimit
+	}
+	if config.Sink == nil {
+		config.Sink = LogCaptureSink()
+	}
+	ids := new(captureIDs)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if config.Skip != nil && config.Skip(req) || req.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			start := time.Now()
+			body := &captureReader{ReadCloser: req.Body, captureBuffer: captureBuffer{limit: config.MaxBytes}}
+			if req.Body != nil && req.Body != http.NoBody {
+				req.Body = body
+			}
+			cw := &captureWriter{ResponseWriter: WrapResponseWriter(w), captureBuffer: captureBuffer{limit: config.MaxBytes}}
+			next.ServeHTTP(cw, req)
+
+			ids.Lock()
+			ids.next++
+			id := ids.next
+			ids.Unlock()
+
+			redaction := RedactionFrom(req.Context())
+			status := cw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			exchange := &CapturedExchange{
+				ID:             id,
+				Time:           start,
+				Method:         req.Method,
+				URL:            redaction.URL(req.URL.RequestURI()),
+				Route:          RoutePattern(req),
+				RequestID:      RequestIDFromContext(req.Context()),
+				Status:         status,
+				Latency:        time.Since(start),
+				RequestHeader:  redaction.Header(req.Header),
+				Request:        config.captured(redaction, req.Header.Get("Content-Type"), &body.captureBuffer),
+				ResponseHeader: redaction.Header(cw.Header()),
+				Response:       config.captured(redaction, cw.Header().Get("Content-Type"), &cw.captureBuffer),
+			}
+			if exchange.RequestID == "" {
+				exchange.RequestID = cw.Header().Get(RequestIDHeader)
+			}
+			config.Sink.Capture(req.Context(), exchange)
+		})
+	}
+}
+
+// captureIDs numbers the exchanges of a BodyCapture middleware.
+type captureIDs struct {
+	sync.Mutex
+	next uint64
+}
+
+// captureBuffer keeps the first bytes of a body and counts the rest.
+type captureBuffer struct {
+	buf   bytes.Buffer
+	limit int64
+	size  int64
+}
+
+func (c *captureBuffer) capture(p []byte) {
+	if room := c.limit - int64(c.buf.Len()); room > 0 {
+		c.buf.Write(p[:min(int64(len(p)), room)])
+	}
+	c.size += int64(len(p))
+}
+
+// captureReader tees a request body.
+type captureReader struct {
+	io.ReadCloser
+	captureBuffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.capture(p[:n])
+	return n, err
+}
+
+// captureWriter tees a response body.
+type captureWriter struct {
+	ResponseWriter
+	captureBuffer
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	// Fill in the Content-Type net/http would sniff, so binaries are skipped.
+	if !w.Written() && len(p) > 0 && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", http.DetectContentType(p))
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.capture(p[:n])
+	return n, err
+}
+
+// Flush flushes the underlying writer if it supports flushing.
+func (w *captureWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer for use with http.ResponseController.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// captured describes a captured body, skipping binaries and redacting the rest.
+func (config *BodyCaptureConfig) captured(redaction *RedactionPolicy, contentType string, c *captureBuffer) CapturedBody {
+	body := CapturedBody{ContentType: contentType, Size: c.size, Truncated: c.size > int64(c.buf.Len())}
+	if c.size == 0 {
+		return body
+	}
+	data := c.buf.Bytes()
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !config.capturesType(mediaType) || mediaType == "" && !utf8.Valid(data) {
+		body.Skipped, body.Truncated = true, false
+		return body
+	}
+
+	body.Body = redactBody(redaction, mediaType, data)
+	return body
+}
+
+// redactBody masks a textual body of the given media type.
+func redactBody(redaction *RedactionPolicy, mediaType string, data []byte) string {
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return string(redaction.JSON(data))
+	case mediaType == "application/x-www-form-urlencoded":
+		return strings.TrimPrefix(redaction.URL("?"+string(data)), "?")
+	default:
+		return redaction.String(string(data))
+	}
+}
+
+// capturesType reports whether bodies of mediaType are captured. Bodies
+// without a type are captured if they are valid UTF-8.
+func (config *BodyCaptureConfig) capturesType(mediaType string) bool {
+	if mediaType == "" {
+		return true
+	}
+	if len(config.ContentTypes) == 0 {
+		return isTextualMediaType(mediaType)
+	}
+	for _, pattern := range config.ContentTypes {
+		if pattern == mediaType || strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTextualMediaType reports whether a media type holds text.
+func isTextualMediaType(mediaType string) bool {
+	switch mediaType {
+	case "application/json", "application/xml", "application/x-www-form-urlencoded",
+		"application/javascript", "application/x-ndjson", "application/graphql":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// CaptureBuffer is a CaptureSink keeping the most recent exchanges in memory.
+// It is also an http.Handler for a debug endpoint: GET lists the exchanges,
+// newest first (limit with ?limit=n), and DELETE clears them. Mount it behind
+// authentication, as bodies may hold data the redaction policy misses.
+type CaptureBuffer struct {
+	mu        sync.Mutex
+	exchanges []*CapturedExchange // ring buffer
+	next      int
+	full      bool
+}
+
+// NewCaptureBuffer creates a CaptureBuffer keeping size exchanges.
+func NewCaptureBuffer(size int) *CaptureBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &CaptureBuffer{exchanges: make([]*CapturedExchange, size)}
+}
+
+// Capture implements CaptureSink.
+func (b *CaptureBuffer) Capture(ctx context.Context, exchange *CapturedExchange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.exchanges[b.next] = exchange
+	b.next = (b.next + 1) % len(b.exchanges)
+	b.full = b.full || b.next == 0
+}
+
+// Exchanges returns the kept exchanges, newest first.
+func (b *CaptureBuffer) Exchanges() []*CapturedExchange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.next
+	if b.full {
+		n = len(b.exchanges)
+	}
+	exchanges := make([]*CapturedExchange, 0, n)
+	for i := 1; i <= n; i++ {
+		exchanges = append(exchanges, b.exchanges[(b.next-i+len(b.exchanges))%len(b.exchanges)])
+	}
+	return exchanges
+}
+
+// Clear removes all exchanges.
+func (b *CaptureBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.exchanges)
+	b.next, b.full = 0, false
+}
+
+// ServeHTTP serves the debug endpoint.
+func (b *CaptureBuffer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		exchanges := b.Exchanges()
+		if limit, err := strconv.Atoi(req.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(exchanges) {
+			exchanges = exchanges[:limit]
+		}
+		Render(w, req, exchanges, http.StatusOK)
+	case http.MethodDelete:
+		b.Clear()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, DELETE")
+		RenderError(w, req, NewHTTPError(http.StatusMethodNotAllowed, ""))
+	}
+}
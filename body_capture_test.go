@@ -0,0 +1,95 @@
+package gorouter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyCapture(t *testing.T) {
+	buffer := NewCaptureBuffer(10)
+	router := NewRouter()
+	router.Use(BodyCapture(BodyCaptureConfig{Sink: buffer, MaxBytes: 64}))
+	router.AddRoute(http.MethodPost, "/login", func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		JSONResponse(w, map[string]string{"token": "t0k3n", "name": "ann"}, http.StatusCreated)
+	})
+	router.AddRoute(http.MethodGet, "/debug/captures", buffer.ServeHTTP)
+
+	req := httptest.NewRequest(http.MethodPost, "/login?api_key=k", strings.NewReader(`{"name":"ann","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abc")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	exchanges := buffer.Exchanges()
+	if len(exchanges) != 1 {
+		t.Fatalf("Expected 1 captured exchange, got %d", len(exchanges))
+	}
+	exchange := exchanges[0]
+	if exchange.URL != "/login?api_key=[REDACTED]" || exchange.Route != "/login" || exchange.Status != http.StatusCreated {
+		t.Errorf("Unexpected exchange %+v", exchange)
+	}
+	if exchange.RequestHeader.Get("Authorization") != RedactedValue {
+		t.Errorf("Expected the Authorization header to be redacted, got %v", exchange.RequestHeader)
+	}
+	if exchange.Request.Body != `{"name":"ann","password":"[REDACTED]"}` || exchange.Request.Size != 35 {
+		t.Errorf("Unexpected request body %+v", exchange.Request)
+	}
+	if exchange.Response.Body != `{"name":"ann","token":"[REDACTED]"}` || exchange.Response.ContentType != "application/json" {
+		t.Errorf("Unexpected response body %+v", exchange.Response)
+	}
+
+	// The debug endpoint lists the exchanges.
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/captures", nil))
+	var listed []CapturedExchange
+	if err := json.Unmarshal(recorder.Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].ID != exchange.ID {
+		t.Errorf("Expected the debug endpoint to list the capture, got %s", recorder.Body.String())
+	}
+}
+
+func TestBodyCaptureLimitsAndBinaries(t *testing.T) {
+	buffer := NewCaptureBuffer(10)
+	handler := BodyCapture(BodyCaptureConfig{Sink: buffer, MaxBytes: 4})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Write([]byte("\x89PNG\r\n\x1a\n...."))
+			return
+		}
+		w.Write([]byte("hello world"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/text", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/image", nil))
+
+	exchanges := buffer.Exchanges()
+	text, image := exchanges[1].Response, exchanges[0].Response
+	if text.Body != "hell" || !text.Truncated || text.Size != 11 || text.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Expected the text body to be truncated, got %+v", text)
+	}
+	if !image.Skipped || image.Body != "" || image.ContentType != "image/png" {
+		t.Errorf("Expected the binary body to be skipped, got %+v", image)
+	}
+}
+
+func TestCaptureBufferRing(t *testing.T) {
+	buffer := NewCaptureBuffer(2)
+	for id := uint64(1); id <= 3; id++ {
+		buffer.Capture(nil, &CapturedExchange{ID: id})
+	}
+	exchanges := buffer.Exchanges()
+	if len(exchanges) != 2 || exchanges[0].ID != 3 || exchanges[1].ID != 2 {
+		t.Errorf("Expected the two newest exchanges, got %v", exchanges)
+	}
+
+	recorder := httptest.NewRecorder()
+	buffer.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/", nil))
+	if recorder.Code != http.StatusNoContent || len(buffer.Exchanges()) != 0 {
+		t.Errorf("Expected DELETE to clear the buffer, got %d", recorder.Code)
+	}
+}
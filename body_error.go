@@ -0,0 +1,110 @@
+package gorouter
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// BodyErrorKind classifies request body decoding failures.
+type BodyErrorKind int
+
+const (
+	BodyInvalid              BodyErrorKind = iota // The body could not be decoded for another reason
+	BodyEmpty                                     // The body is empty
+	BodySyntax                                    // The body is malformed
+	BodyTypeMismatch                              // A value has the wrong type for its field
+	BodyUnknownField                              // The body contains a field dst does not have
+	BodyTooLarge                                  // The body exceeds the maximum size
+	BodyUnsupportedMediaType                      // No decoder is registered for the Content-Type
+)
+
+// String returns the error code used in responses for the kind.
+func (k BodyErrorKind) String() string {
+	switch k {
+	case BodyEmpty:
+		return "empty_body"
+	case BodySyntax:
+		return "malformed_body"
+	case BodyTypeMismatch:
+		return "invalid_field_type"
+	case BodyUnknownField:
+		return "unknown_field"
+	case BodyTooLarge:
+		return "body_too_large"
+	case BodyUnsupportedMediaType:
+		return "unsupported_media_type"
+	default:
+		return "invalid_body"
+	}
+}
+
+// BodyError is returned when a request body cannot be decoded.
+type BodyError struct {
+	Kind   BodyErrorKind
+	Field  string // Path of the offending field, e.g. "address.zip", if known
+	Offset int64  // Byte offset of the error in the body, if known
+	Err    error  // Underlying decoder error
+}
+
+// Error implements the error interface.
+func (e *BodyError) Error() string {
+	if e.Err == nil {
+		return e.message()
+	}
+	return fmt.Sprintf("%s: %v", e.message(), e.Err)
+}
+
+// Unwrap returns the underlying decoder error.
+func (e *BodyError) Unwrap() error {
+	return e.Err
+}
+
+// message returns a description of the error that is safe to show to clients.
+func (e *BodyError) message() string {
+	switch e.Kind {
+	case BodyEmpty:
+		return "Request body must not be empty"
+	case BodySyntax:
+		if e.Offset > 0 {
+			return fmt.Sprintf("Request body is malformed at position %d", e.Offset)
+		}
+		return "Request body is malformed"
+	case BodyTypeMismatch:
+		if e.Field != "" {
+			return fmt.Sprintf("Request body field %q has the wrong type", e.Field)
+		}
+		return "Request body contains a value of the wrong type"
+	case BodyUnknownField:
+		return fmt.Sprintf("Request body contains unknown field %q", e.Field)
+	case BodyTooLarge:
+		return "Request body is too large"
+	case BodyUnsupportedMediaType:
+		return "Unsupported Content-Type"
+	default:
+		return "Request body is invalid"
+	}
+}
+
+// httpError converts the BodyError into the HTTPError rendered for it.
+func (e *BodyError) httpError() *HTTPError {
+	status := http.StatusBadRequest
+	switch e.Kind {
+	case BodyTooLarge:
+		status = http.StatusRequestEntityTooLarge
+	case BodyUnsupportedMediaType:
+		status = http.StatusUnsupportedMediaType
+	}
+
+	httpErr := NewHTTPError(status, e.message()).WithCode(e.Kind.String()).Wrap(e)
+	if e.Field != "" || e.Offset > 0 {
+		details := map[string]interface{}{}
+		if e.Field != "" {
+			details["field"] = e.Field
+		}
+		if e.Offset > 0 {
+			details["offset"] = e.Offset
+		}
+		httpErr.Details = details
+	}
+	return httpErr
+}
@@ -0,0 +1,65 @@
+// Command replay sends the requests of a traffic recording written by
+// gorouter.Record to a live server and reports responses that differ from
+// the recorded ones. It exits with status 1 if any request fails.
+//
+//	replay -addr http://localhost:8080 traffic.jsonl
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/saadi925/gorouter"
+)
+
+func main() {
+	addr := flag.String("addr", "http://localhost:8080", "base URL of the server to replay against")
+	headers := flag.String("headers", "Content-Type", "comma-separated response headers to compare")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of each request")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: replay [flags] recording.jsonl\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := gorouter.ReplayFile(ctx, flag.Arg(0), gorouter.ReplayConfig{
+		BaseURL: *addr,
+		Client:  &http.Client{Timeout: *timeout},
+		Headers: strings.Split(*headers, ","),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Passed() {
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s %s\n", result.Recording.Request.Method, result.Recording.Request.URL)
+		if result.Err != nil {
+			fmt.Printf("\t%v\n", result.Err)
+		}
+		for _, mismatch := range result.Mismatches {
+			fmt.Printf("\t%s\n", mismatch)
+		}
+	}
+	fmt.Printf("%d replayed, %d failed\n", len(results), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,306 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultShedRetryAfter is the Retry-After sent with shed requests when
+// ConcurrencyLimiterConfig leaves it unset.
+const DefaultShedRetryAfter = time.Second
+
+// ErrOverloaded is returned by ConcurrencyLimiter.Acquire when a request is shed.
+var ErrOverloaded = errors.New("gorouter: server overloaded")
+
+// Priority orders queued requests; higher priorities are admitted first.
+type Priority int
+
+// Common priority classes.
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// AdaptiveConcurrency configures a ConcurrencyLimiter to adjust its limit
+// from observed latency: the limit grows by one per limit's worth of fast
+// requests and is multiplied by Backoff when requests are slower than
+// TargetLatency (AIMD).
+type AdaptiveConcurrency struct {
+	TargetLatency time.Duration // Latency above which the limit is decreased
+	MinLimit      int           // Lowest limit; defaults to 1
+	Backoff       float64       // Multiplier applied on decrease; defaults to 0.9
+	Cooldown      time.Duration // Minimum time between decreases; defaults to TargetLatency
+}
+
+// ConcurrencyLimiterConfig represents configuration options for a ConcurrencyLimiter.
+type ConcurrencyLimiterConfig struct {
+	MaxInFlight int                              // Requests handled at once; the upper bound in adaptive mode
+	MaxQueue    int                              // Requests waiting for a slot; zero sheds as soon as the limit is reached
+	MaxWait     time.Duration                    // Longest time a request waits in the queue; zero waits as long as the request context allows
+	Priority    func(req *http.Request) Priority // Defaults to PriorityNormal for every request
+	Adaptive    *AdaptiveConcurrency             // Adjust the limit from latency; nil keeps MaxInFlight fixed
+	RetryAfter  time.Duration                    // Sent with shed requests; defaults to DefaultShedRetryAfter
+	OnReject    http.HandlerFunc                 // Writes the response for shed requests; defaults to a 503 error
+}
+
+// ConcurrencyLimiter caps the number of requests handled at once. Requests
+// over the cap wait in a bounded priority queue and are shed with 503 and
+// Retry-After when it is full or they wait too long.
+type ConcurrencyLimiter struct {
+	config       ConcurrencyLimiterConfig
+	now          func() time.Time
+	mu           sync.Mutex
+	limit        float64
+	inFlight     int
+	queue        []*concurrencyWaiter
+	seq          uint64
+	lastDecrease time.Time
+}
+
+// concurrencyWaiter is a request waiting in the queue.
+type concurrencyWaiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan error // receives nil when admitted or ErrOverloaded when displaced
+}
+
+// NewConcurrencyLimiter creates a ConcurrencyLimiter.
+func NewConcurrencyLimiter(config ConcurrencyLimiterConfig) *ConcurrencyLimiter {
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = 1
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = DefaultShedRetryAfter
+	}
+	if adaptive := config.Adaptive; adaptive != nil {
+		copied := *adaptive
+		if copied.MinLimit <= 0 {
+			copied.MinLimit = 1
+		}
+		if copied.Backoff <= 0 || copied.Backoff >= 1 {
+			copied.Backoff = 0.9
+		}
+		if copied.Cooldown <= 0 {
+			copied.Cooldown = copied.TargetLatency
+		}
+		config.Adaptive = &copied
+	}
+	return &ConcurrencyLimiter{config: config, now: time.Now, limit: float64(config.MaxInFlight)}
+}
+
+// CurrentLimit returns the current limit on requests in flight.
+func (l *ConcurrencyLimiter) CurrentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of requests being handled.
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// Queued returns the number of requests waiting.
+func (l *ConcurrencyLimiter) Queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queue)
+}
+
+// Acquire waits for a slot and returns a func that frees it, which must be
+// called once the work is done. It returns ErrOverloaded if the request is
+// shed, or the context's error if it ends first.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, priority Priority) (release func(), err error) {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) && len(l.queue) == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return l.releaser(), nil
+	}
+	w, err := l.enqueue(priority)
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var timeout <-chan time.Time
+	if l.config.MaxWait > 0 {
+		timer := time.NewTimer(l.config.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case err := <-w.ready:
+		if err != nil {
+			return nil, err
+		}
+		return l.releaser(), nil
+	case <-timeout:
+		err = ErrOverloaded
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.dequeue(w) {
+		// The request was admitted or displaced while giving up.
+		if err := <-w.ready; err != nil {
+			return nil, err
+		}
+		return l.releaser(), nil
+	}
+	return nil, err
+}
+
+// enqueue adds a waiter, displacing the newest lowest priority waiter if the
+// queue is full and it has a lower priority.
+func (l *ConcurrencyLimiter) enqueue(priority Priority) (*concurrencyWaiter, error) {
+	if len(l.queue) >= l.config.MaxQueue {
+		lowest := -1
+		for i, w := range l.queue {
+			if lowest < 0 || w.priority < l.queue[lowest].priority ||
+				w.priority == l.queue[lowest].priority && w.seq > l.queue[lowest].seq {
+				lowest = i
+			}
+		}
+		if lowest < 0 || l.queue[lowest].priority >= priority {
+			return nil, ErrOverloaded
+		}
+		displaced := l.queue[lowest]
+		l.dequeue(displaced)
+		displaced.ready <- ErrOverloaded
+	}
+	l.seq++
+	w := &concurrencyWaiter{priority: priority, seq: l.seq, ready: make(chan error, 1)}
+	l.queue = append(l.queue, w)
+	return w, nil
+}
+
+// dequeue removes w from the queue and reports whether it was there.
+func (l *ConcurrencyLimiter) dequeue(w *concurrencyWaiter) bool {
+	for i, queued := range l.queue {
+		if queued == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// admit hands free slots to the highest priority, oldest waiters.
+func (l *ConcurrencyLimiter) admit() {
+	for l.inFlight < int(l.limit) && len(l.queue) > 0 {
+		next := 0
+		for i, w := range l.queue {
+			if w.priority > l.queue[next].priority {
+				next = i
+			}
+		}
+		w := l.queue[next]
+		l.queue = append(l.queue[:next], l.queue[next+1:]...)
+		l.inFlight++
+		w.ready <- nil
+	}
+}
+
+// releaser returns the func freeing a slot taken now.
+func (l *ConcurrencyLimiter) releaser() func() {
+	start := l.now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.inFlight--
+			l.adapt(l.now().Sub(start))
+			l.admit()
+		})
+	}
+}
+
+// adapt updates the limit after a request that took latency.
+func (l *ConcurrencyLimiter) adapt(latency time.Duration) {
+	adaptive := l.config.Adaptive
+	if adaptive == nil {
+		return
+	}
+	now := l.now()
+	if latency > adaptive.TargetLatency {
+		if now.Sub(l.lastDecrease) >= adaptive.Cooldown {
+			l.limit = math.Max(float64(adaptive.MinLimit), math.Floor(l.limit*adaptive.Backoff))
+			l.lastDecrease = now
+		}
+		return
+	}
+	// Only grow while the limit is being used, so idle periods do not inflate it.
+	if float64(l.inFlight+1)*2 >= l.limit {
+		l.limit = math.Min(float64(l.config.MaxInFlight), l.limit+1/l.limit)
+	}
+}
+
+// Limit is a middleware applying the limit to each request.
+func (l *ConcurrencyLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		priority := PriorityNormal
+		if l.config.Priority != nil {
+			priority = l.config.Priority(req)
+		}
+		release, err := l.Acquire(req.Context(), priority)
+		if err != nil {
+			if errors.Is(err, ErrOverloaded) {
+				l.shed(w, req)
+			}
+			// The client is gone otherwise.
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// shed writes the response for a shed request.
+func (l *ConcurrencyLimiter) shed(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(l.config.RetryAfter), 10))
+	if l.config.OnReject != nil {
+		l.config.OnReject(w, req)
+		return
+	}
+	// Shedding is expected under load, so it is not logged like other 503s.
+	writeError(w, req, NewHTTPError(http.StatusServiceUnavailable, "Server overloaded"))
+}
+
+// ConcurrencyPerRoute is a middleware giving each route pattern its own
+// ConcurrencyLimiter with config, so one slow route cannot use up the
+// capacity of the others. It works in Router middleware too, since routes are
+// matched before any middleware runs; requests matching no route (404 and 405
+// responses) all share the limiter keyed by "".
+func ConcurrencyPerRoute(config ConcurrencyLimiterConfig) Middleware {
+	var limiters sync.Map // of route pattern to *ConcurrencyLimiter
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			pattern := RoutePattern(req)
+			limiter, ok := limiters.Load(pattern)
+			if !ok {
+				limiter, _ = limiters.LoadOrStore(pattern, NewConcurrencyLimiter(config))
+			}
+			limiter.(*ConcurrencyLimiter).Limit(next).ServeHTTP(w, req)
+		})
+	}
+}
+
+// LimitConcurrency limits the routes added to the group afterwards with a new
+// ConcurrencyLimiter shared by all of them, which is returned.
+func (rg *RouteGroup) LimitConcurrency(config ConcurrencyLimiterConfig) *ConcurrencyLimiter {
+	limiter := NewConcurrencyLimiter(config)
+	rg.Use(limiter.Limit)
+	return limiter
+}
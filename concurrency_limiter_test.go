@@ -0,0 +1,202 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrencyLimiterSheds(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxInFlight: 1})
+	unblock := make(chan struct{})
+	handler := limiter.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.Write([]byte("ok"))
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		done <- recorder
+	}()
+	waitFor(t, func() bool { return limiter.InFlight() == 1 })
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	checkResponse(t, recorder, http.StatusServiceUnavailable, `{"error":"Server overloaded"}`+"\n", "Retry-After", "1")
+
+	close(unblock)
+	checkResponse(t, <-done, http.StatusOK, "ok", "", "")
+	if limiter.InFlight() != 0 {
+		t.Errorf("Expected the slot to be released, got %d in flight", limiter.InFlight())
+	}
+}
+
+func TestConcurrencyLimiterRouterUse(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxInFlight: 1})
+	router := NewRouter()
+	router.Use(limiter.Limit)
+	router.AddRoute(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		if limiter.InFlight() != 1 {
+			t.Errorf("Expected the request to hold one slot, got %d", limiter.InFlight())
+		}
+		w.Write([]byte("ok"))
+	})
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		checkResponse(t, recorder, http.StatusOK, "ok", "", "")
+	}
+}
+
+func TestConcurrencyLimiterPriorityQueue(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxInFlight: 1, MaxQueue: 2})
+	release, err := limiter.Acquire(context.Background(), PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := make(chan Priority, 3)
+	errs := make(chan error, 3)
+	acquire := func(priority Priority) {
+		release, err := limiter.Acquire(context.Background(), priority)
+		if err != nil {
+			errs <- err
+			return
+		}
+		admitted <- priority
+		release()
+	}
+
+	go acquire(PriorityLow)
+	waitFor(t, func() bool { return limiter.Queued() == 1 })
+	go acquire(PriorityNormal)
+	waitFor(t, func() bool { return limiter.Queued() == 2 })
+
+	// A full queue displaces its lowest priority waiter for a higher one.
+	go acquire(PriorityHigh)
+	if err := <-errs; !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected the low priority request to be shed, got %v", err)
+	}
+	waitFor(t, func() bool { return limiter.Queued() == 2 })
+	if _, err := limiter.Acquire(context.Background(), PriorityLow); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected a low priority request to be shed from a full queue, got %v", err)
+	}
+
+	release()
+	if first, second := <-admitted, <-admitted; first != PriorityHigh || second != PriorityNormal {
+		t.Errorf("Expected admission by priority, got %v then %v", first, second)
+	}
+}
+
+func TestConcurrencyLimiterMaxWait(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxInFlight: 1, MaxQueue: 1, MaxWait: 20 * time.Millisecond})
+	release, _ := limiter.Acquire(context.Background(), PriorityNormal)
+	defer release()
+
+	start := time.Now()
+	if _, err := limiter.Acquire(context.Background(), PriorityNormal); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected the request to be shed after waiting, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected the request to wait, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limiter.Acquire(ctx, PriorityNormal); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+	if limiter.Queued() != 0 {
+		t.Errorf("Expected abandoned waiters to leave the queue, got %d", limiter.Queued())
+	}
+}
+
+func TestConcurrencyLimiterAdaptive(t *testing.T) {
+	now := time.Now()
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{
+		MaxInFlight: 10,
+		Adaptive:    &AdaptiveConcurrency{TargetLatency: 100 * time.Millisecond, MinLimit: 8},
+	})
+	limiter.now = func() time.Time { return now }
+
+	slow := func() {
+		release, _ := limiter.Acquire(context.Background(), PriorityNormal)
+		now = now.Add(200 * time.Millisecond)
+		release()
+	}
+	slow()
+	if limiter.CurrentLimit() != 9 {
+		t.Errorf("Expected slow requests to lower the limit to 9, got %d", limiter.CurrentLimit())
+	}
+	slow()
+	slow()
+	if limiter.CurrentLimit() != 8 {
+		t.Errorf("Expected the limit to stop at MinLimit, got %d", limiter.CurrentLimit())
+	}
+
+	// Fast requests raise the limit again while it is in use.
+	for round := 0; round < 5; round++ {
+		var releases []func()
+		for i := 0; i < 8; i++ {
+			release, _ := limiter.Acquire(context.Background(), PriorityNormal)
+			releases = append(releases, release)
+		}
+		for _, release := range releases {
+			release()
+		}
+	}
+	if limiter.CurrentLimit() != 10 {
+		t.Errorf("Expected the limit to recover to MaxInFlight, got %d", limiter.CurrentLimit())
+	}
+}
+
+func TestConcurrencyPerRoute(t *testing.T) {
+	router := NewRouter()
+	api := router.Group("/api")
+	api.Use(ConcurrencyPerRoute(ConcurrencyLimiterConfig{MaxInFlight: 1}))
+
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	api.GET("/slow", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+	})
+	api.GET("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fast"))
+	})
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+		close(done)
+	}()
+	<-entered
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/fast", nil))
+	checkResponse(t, recorder, http.StatusOK, "fast", "", "")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+	checkResponse(t, recorder, http.StatusServiceUnavailable, `{"error":"Server overloaded"}`+"\n", "Retry-After", "1")
+
+	close(unblock)
+	<-done
+}
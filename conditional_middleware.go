@@ -0,0 +1,154 @@
+package gorouter
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequestPredicate reports whether a request satisfies a condition.
+type RequestPredicate func(req *http.Request) bool
+
+// When applies the middleware only to requests for which the predicate returns true.
+// Other requests go straight to the next handler.
+func When(predicate RequestPredicate, mw Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if predicate(req) {
+				wrapped.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// Unless applies the middleware only to requests for which the predicate returns false.
+func Unless(predicate RequestPredicate, mw Middleware) Middleware {
+	return When(Not(predicate), mw)
+}
+
+// Skip applies the middleware to every request except those matching one of the paths.
+// Paths may be exact ("/health"), contain parameters ("/users/:id") or end with
+// a "*" wildcard ("/metrics/*").
+func Skip(mw Middleware, paths ...string) Middleware {
+	return Unless(PathIs(paths...), mw)
+}
+
+// Only applies the middleware to requests matching one of the paths.
+// Paths follow the same rules as in Skip.
+func Only(mw Middleware, paths ...string) Middleware {
+	return When(PathIs(paths...), mw)
+}
+
+// OnlyMethods applies the middleware to requests using one of the HTTP methods.
+func OnlyMethods(mw Middleware, methods ...string) Middleware {
+	return When(MethodIs(methods...), mw)
+}
+
+// SkipMethods applies the middleware to every request except those using one of the HTTP methods.
+func SkipMethods(mw Middleware, methods ...string) Middleware {
+	return Unless(MethodIs(methods...), mw)
+}
+
+// OnlyHeader applies the middleware to requests carrying the header.
+// An empty value matches any value of the header.
+func OnlyHeader(mw Middleware, name, value string) Middleware {
+	return When(HeaderIs(name, value), mw)
+}
+
+// SkipHeader applies the middleware to every request except those carrying the header.
+// An empty value matches any value of the header.
+func SkipHeader(mw Middleware, name, value string) Middleware {
+	return Unless(HeaderIs(name, value), mw)
+}
+
+// PathIs returns a predicate matching requests whose path matches one of the paths.
+func PathIs(paths ...string) RequestPredicate {
+	return func(req *http.Request) bool {
+		for _, path := range paths {
+			if matchPath(path, req.URL.Path) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MethodIs returns a predicate matching requests using one of the HTTP methods.
+func MethodIs(methods ...string) RequestPredicate {
+	return func(req *http.Request) bool {
+		for _, method := range methods {
+			if strings.EqualFold(method, req.Method) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HeaderIs returns a predicate matching requests carrying the header.
+// An empty value matches any value of the header.
+func HeaderIs(name, value string) RequestPredicate {
+	return func(req *http.Request) bool {
+		values := req.Header.Values(name)
+		if len(values) == 0 {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not negates a predicate.
+func Not(predicate RequestPredicate) RequestPredicate {
+	return func(req *http.Request) bool {
+		return !predicate(req)
+	}
+}
+
+// And returns a predicate matching requests that satisfy all predicates.
+func And(predicates ...RequestPredicate) RequestPredicate {
+	return func(req *http.Request) bool {
+		for _, predicate := range predicates {
+			if !predicate(req) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a predicate matching requests that satisfy at least one predicate.
+func Or(predicates ...RequestPredicate) RequestPredicate {
+	return func(req *http.Request) bool {
+		for _, predicate := range predicates {
+			if predicate(req) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchPath matches a request path against an exact, parameterized or wildcard pattern.
+func matchPath(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	if pattern == path {
+		return true
+	}
+	if strings.Contains(pattern, ":") {
+		matched, _ := matchPathWithParams(pattern, path)
+		return matched
+	}
+	return false
+}
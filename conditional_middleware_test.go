@@ -0,0 +1,72 @@
+package gorouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// markMiddleware sets a header so tests can tell whether it ran.
+func markMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Applied", "true")
+		next.ServeHTTP(w, req)
+	})
+}
+
+func TestConditionalMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name    string
+		mw      Middleware
+		method  string
+		path    string
+		header  string
+		applied bool
+	}{
+		{"skip exact path", Skip(markMiddleware, "/health", "/metrics"), http.MethodGet, "/health", "", false},
+		{"skip other path", Skip(markMiddleware, "/health"), http.MethodGet, "/users", "", true},
+		{"skip wildcard", Skip(markMiddleware, "/debug/*"), http.MethodGet, "/debug/pprof", "", false},
+		{"only param path", Only(markMiddleware, "/users/:id"), http.MethodGet, "/users/42", "", true},
+		{"only other path", Only(markMiddleware, "/users/:id"), http.MethodGet, "/posts/42", "", false},
+		{"only methods match", OnlyMethods(markMiddleware, http.MethodPost), http.MethodPost, "/users", "", true},
+		{"only methods miss", OnlyMethods(markMiddleware, http.MethodPost), http.MethodGet, "/users", "", false},
+		{"skip methods", SkipMethods(markMiddleware, http.MethodOptions), http.MethodOptions, "/users", "", false},
+		{"only header", OnlyHeader(markMiddleware, "X-Debug", ""), http.MethodGet, "/", "1", true},
+		{"skip header", SkipHeader(markMiddleware, "X-Debug", "1"), http.MethodGet, "/", "1", false},
+		{"when and", When(And(MethodIs(http.MethodGet), PathIs("/a")), markMiddleware), http.MethodGet, "/a", "", true},
+		{"when or", When(Or(MethodIs(http.MethodPut), PathIs("/b")), markMiddleware), http.MethodGet, "/a", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Debug", tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			tt.mw(ok).ServeHTTP(recorder, req)
+
+			applied := recorder.Header().Get("X-Applied") == "true"
+			if applied != tt.applied {
+				t.Errorf("Expected middleware applied=%v, got %v", tt.applied, applied)
+			}
+		})
+	}
+}
+
+func TestSkipRateLimiter(t *testing.T) {
+	r := NewRouter()
+	r.Use(Skip(NewRateLimiter(0, 0).Limit, "/health"))
+	r.AddRoute(http.MethodGet, "/health", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	checkResponse(t, recorder, http.StatusOK, "ok", "", "")
+}
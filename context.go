@@ -0,0 +1,151 @@
+package gorouter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Context wraps the response writer and request of a single request and offers
+// helpers for the common handler tasks. A Context is pooled and must not be
+// used after its handler returns.
+type Context struct {
+	Writer  ResponseWriter
+	Request *http.Request
+
+	query  QueryParams
+	values map[string]interface{}
+}
+
+// ContextHandler is a handler that receives a Context instead of a ResponseWriter and Request.
+type ContextHandler func(c *Context)
+
+// ServeHTTP implements the http.Handler interface for ContextHandler.
+func (h ContextHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	c := acquireContext(w, req)
+	defer releaseContext(c)
+	h(c)
+}
+
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		return &Context{}
+	},
+}
+
+// acquireContext takes a Context from the pool and binds it to the request.
+func acquireContext(w http.ResponseWriter, req *http.Request) *Context {
+	c := contextPool.Get().(*Context)
+	c.Writer = WrapResponseWriter(w)
+	c.Request = req
+	return c
+}
+
+// releaseContext clears the Context and returns it to the pool.
+func releaseContext(c *Context) {
+	c.Writer = nil
+	c.Request = nil
+	c.query = nil
+	clear(c.values)
+	contextPool.Put(c)
+}
+
+// Context returns the context of the underlying request.
+func (c *Context) Context() context.Context {
+	return c.Request.Context()
+}
+
+// Params returns the path parameters of the request.
+func (c *Context) Params() Params {
+	params, _ := c.Request.Context().Value(ParamsContextKey).(Params)
+	return params
+}
+
+// Param returns the value of the specified path parameter.
+func (c *Context) Param(key string) string {
+	return c.Params().Get(key)
+}
+
+// ParamInt returns the integer value of the specified path parameter, or 0 if it is not a valid integer.
+func (c *Context) ParamInt(key string) int {
+	return c.Params().GetInt(key)
+}
+
+// QueryParams returns the query parameters of the request.
+func (c *Context) QueryParams() QueryParams {
+	if c.query == nil {
+		c.query = ParseQueryParams(c.Request)
+	}
+	return c.query
+}
+
+// Query returns the value of the specified query parameter.
+func (c *Context) Query(key string) string {
+	return c.QueryParams().Get(key)
+}
+
+// Header returns the value of the specified request header.
+func (c *Context) Header(key string) string {
+	return c.Request.Header.Get(key)
+}
+
+// Bind decodes the request body into dst with the decoder registered for its
+// Content-Type. Unlike ParseJSONBody it does not write a response; returning
+// the error from an error-returning handler renders it with a matching status.
+func (c *Context) Bind(dst interface{}) error {
+	return DecodeBody(c.Request, dst)
+}
+
+// Status sends a response with the given status code and no body.
+func (c *Context) Status(statusCode int) {
+	c.Writer.WriteHeader(statusCode)
+}
+
+// JSON sends a JSON response with the given data and status code.
+func (c *Context) JSON(data interface{}, statusCode int) {
+	WriteJSON(c.Writer, c.Request, data, statusCode)
+}
+
+// Render sends data in the media type negotiated from the Accept header.
+func (c *Context) Render(data interface{}, statusCode int) {
+	Render(c.Writer, c.Request, data, statusCode)
+}
+
+// String sends a plain text response with the given status code.
+func (c *Context) String(text string, statusCode int) {
+	c.Writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.Writer.WriteHeader(statusCode)
+	c.Writer.Write([]byte(text))
+}
+
+// HTML sends an HTML response with the given status code.
+func (c *Context) HTML(html string, statusCode int) {
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Writer.WriteHeader(statusCode)
+	c.Writer.Write([]byte(html))
+}
+
+// Redirect redirects the request to the given URL with the given status code.
+func (c *Context) Redirect(url string, statusCode int) {
+	http.Redirect(c.Writer, c.Request, url, statusCode)
+}
+
+// Set stores a value for the lifetime of the request.
+func (c *Context) Set(key string, value interface{}) {
+	if c.values == nil {
+		c.values = make(map[string]interface{})
+	}
+	c.values[key] = value
+}
+
+// Get returns a value stored with Set.
+func (c *Context) Get(key string) (interface{}, bool) {
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// Dep retrieves a dependency registered on the router, route group or
+// DependencyRegistry middleware and asserts it to type T.
+func Dep[T any](c *Context, key string) (T, error) {
+	return Resolve[T](c.Request.Context(), key)
+}
@@ -0,0 +1,79 @@
+package gorouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContextHandler(t *testing.T) {
+	r := NewRouter()
+	users := r.Group("/users")
+	users.Provide("greeting", "hello")
+
+	users.GET("/:id", Adapt(func(c *Context) {
+		greeting, err := Dep[string](c, "greeting")
+		if err != nil {
+			c.String(err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.Set("id", c.Param("id"))
+		id, _ := c.Get("id")
+		c.JSON(map[string]interface{}{
+			"greeting": greeting,
+			"id":       id,
+			"name":     c.Query("name"),
+		}, http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7?name=ada", nil)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	checkResponse(t, recorder, http.StatusOK, `{"greeting":"hello","id":"7","name":"ada"}`+"\n", "Content-Type", "application/json")
+}
+
+func TestContextBind(t *testing.T) {
+	r := NewRouter()
+	r.AddRoute(http.MethodPost, "/echo", Adapt(ContextHandler(func(c *Context) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := c.Bind(&body); err != nil {
+			c.String("bad request", http.StatusBadRequest)
+			return
+		}
+		c.String(body.Name, http.StatusCreated)
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"name":"gopher"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	checkResponse(t, recorder, http.StatusCreated, "gopher", "", "")
+
+	req = httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"unknown":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	checkResponse(t, recorder, http.StatusBadRequest, "bad request", "", "")
+}
+
+func TestDepWrongType(t *testing.T) {
+	r := NewRouter()
+	group := r.Group("/")
+	group.Provide("count", 3)
+	group.GET("dep", Adapt(func(c *Context) {
+		if _, err := Dep[string](c, "count"); err == nil {
+			t.Error("Expected error for dependency of the wrong type")
+		}
+		c.Status(http.StatusNoContent)
+	}))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/dep", nil))
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, recorder.Code)
+	}
+}
@@ -0,0 +1,238 @@
+package gorouter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// DefaultMaxBodySize is the request body limit applied by DecodeBody unless
+// the Router sets another one with SetMaxBodySize.
+const DefaultMaxBodySize int64 = 10 << 20 // 10 MB
+
+// DefaultMaxMultipartMemory is the part of a multipart body kept in memory;
+// the rest of the files are stored on disk.
+const DefaultMaxMultipartMemory int64 = 32 << 20 // 32 MB
+
+// Decoder decodes a request body into dst. Decoders should report failures as *BodyError.
+type Decoder interface {
+	Decode(req *http.Request, dst interface{}) error
+}
+
+// DecoderFunc adapts a function to the Decoder interface.
+type DecoderFunc func(req *http.Request, dst interface{}) error
+
+// Decode calls f(req, dst).
+func (f DecoderFunc) Decode(req *http.Request, dst interface{}) error {
+	return f(req, dst)
+}
+
+// Built-in decoders registered by default.
+var (
+	// JSONDecoder decodes a single JSON value and rejects unknown fields.
+	JSONDecoder Decoder = DecoderFunc(func(req *http.Request, dst interface{}) error {
+		dec := json.NewDecoder(req.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(dst); err != nil {
+			return jsonBodyError(err)
+		}
+		offset := dec.InputOffset()
+		if _, err := dec.Token(); err != io.EOF {
+			return &BodyError{Kind: BodySyntax, Offset: offset, Err: errors.New("body must contain a single JSON value")}
+		}
+		return nil
+	})
+
+	XMLDecoder Decoder = DecoderFunc(func(req *http.Request, dst interface{}) error {
+		dec := xml.NewDecoder(req.Body)
+		if err := dec.Decode(dst); err != nil {
+			var syntaxErr *xml.SyntaxError
+			var numErr *strconv.NumError
+			switch {
+			case errors.As(err, &syntaxErr):
+				return &BodyError{Kind: BodySyntax, Offset: dec.InputOffset(), Err: err}
+			case errors.As(err, &numErr):
+				return &BodyError{Kind: BodyTypeMismatch, Offset: dec.InputOffset(), Err: err}
+			default:
+				return bodyError(err, BodyInvalid)
+			}
+		}
+		return nil
+	})
+
+	// FormDecoder decodes application/x-www-form-urlencoded bodies; see BindValues.
+	FormDecoder Decoder = DecoderFunc(func(req *http.Request, dst interface{}) error {
+		if err := req.ParseForm(); err != nil {
+			return bodyError(err, BodySyntax)
+		}
+		return BindValues(req.PostForm, nil, dst)
+	})
+
+	// MultipartDecoder decodes multipart/form-data bodies, including files; see BindValues.
+	MultipartDecoder Decoder = DecoderFunc(func(req *http.Request, dst interface{}) error {
+		if err := req.ParseMultipartForm(DefaultMaxMultipartMemory); err != nil {
+			return bodyError(err, BodySyntax)
+		}
+		return BindValues(req.MultipartForm.Value, req.MultipartForm.File, dst)
+	})
+
+	// MessagePackDecoder uses json struct tags, like MessagePackEncoder, and rejects unknown fields.
+	MessagePackDecoder Decoder = DecoderFunc(func(req *http.Request, dst interface{}) error {
+		dec := msgpack.NewDecoder(req.Body)
+		dec.SetCustomStructTag("json")
+		dec.DisallowUnknownFields(true)
+		if err := dec.Decode(dst); err != nil {
+			message := err.Error()
+			switch {
+			case strings.HasPrefix(message, "msgpack: unknown field "):
+				field, _ := strconv.Unquote(strings.TrimPrefix(message, "msgpack: unknown field "))
+				return &BodyError{Kind: BodyUnknownField, Field: field, Err: err}
+			case strings.HasPrefix(message, "msgpack: invalid code"):
+				return &BodyError{Kind: BodyTypeMismatch, Err: err}
+			default:
+				return bodyError(err, BodySyntax)
+			}
+		}
+		return nil
+	})
+
+	// CBORDecoder rejects unknown fields.
+	CBORDecoder Decoder = DecoderFunc(func(req *http.Request, dst interface{}) error {
+		if err := cborDecMode.NewDecoder(req.Body).Decode(dst); err != nil {
+			var syntaxErr *cbor.SyntaxError
+			var typeErr *cbor.UnmarshalTypeError
+			var unknownErr *cbor.UnknownFieldError
+			switch {
+			case errors.As(err, &syntaxErr):
+				return &BodyError{Kind: BodySyntax, Err: err}
+			case errors.As(err, &typeErr):
+				return &BodyError{Kind: BodyTypeMismatch, Field: typeErr.StructFieldName, Err: err}
+			case errors.As(err, &unknownErr):
+				return &BodyError{Kind: BodyUnknownField, Err: err}
+			default:
+				return bodyError(err, BodyInvalid)
+			}
+		}
+		return nil
+	})
+)
+
+var cborDecMode, _ = cbor.DecOptions{ExtraReturnErrors: cbor.ExtraDecErrorUnknownField}.DecMode()
+
+// defaultDecoders are used by DecodeBody when the Router has no custom decoders.
+var defaultDecoders = map[string]Decoder{
+	"application/json":                  JSONDecoder,
+	"application/xml":                   XMLDecoder,
+	"text/xml":                          XMLDecoder,
+	"application/x-www-form-urlencoded": FormDecoder,
+	"multipart/form-data":               MultipartDecoder,
+	"application/msgpack":               MessagePackDecoder,
+	"application/x-msgpack":             MessagePackDecoder,
+	"application/cbor":                  CBORDecoder,
+}
+
+// RegisterDecoder registers a decoder used by DecodeBody for the media type,
+// replacing any decoder already registered for it.
+func (r *Router) RegisterDecoder(mediaType string, decoder Decoder) {
+	if r.decoders == nil {
+		r.decoders = make(map[string]Decoder, len(defaultDecoders)+1)
+		for key, value := range defaultDecoders {
+			r.decoders[key] = value
+		}
+	}
+	r.decoders[strings.ToLower(mediaType)] = decoder
+}
+
+// SetMaxBodySize sets the request body limit applied by DecodeBody and
+// ParseJSONBody. Zero restores DefaultMaxBodySize and a negative size disables the limit.
+func (r *Router) SetMaxBodySize(size int64) {
+	r.maxBodySize = size
+}
+
+// DecodeBody decodes the request body into dst with the decoder registered for
+// its Content-Type. Media type parameters such as charset are ignored, and
+// structured syntax suffixes ("+json", "+xml", "+cbor") fall back to the
+// decoder of the base format. Failures are returned as *BodyError and are
+// rendered with a matching status by RenderError. No response is written.
+func DecodeBody(req *http.Request, dst interface{}) error {
+	decoders := defaultDecoders
+	r := routerFromContext(req.Context())
+	if r != nil && r.decoders != nil {
+		decoders = r.decoders
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return &BodyError{Kind: BodyUnsupportedMediaType, Err: err}
+	}
+	decoder, ok := decoders[mediaType]
+	if !ok {
+		if _, suffix, found := strings.Cut(mediaType, "+"); found {
+			decoder, ok = decoders["application/"+suffix]
+		}
+	}
+	if !ok {
+		return &BodyError{Kind: BodyUnsupportedMediaType, Err: fmt.Errorf("no decoder for %s", mediaType)}
+	}
+	return decodeWith(req, decoder, dst)
+}
+
+// decodeWith limits the request body to the configured size and decodes it.
+func decodeWith(req *http.Request, decoder Decoder, dst interface{}) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return &BodyError{Kind: BodyEmpty}
+	}
+	if limit := maxBodySize(req); limit > 0 {
+		req.Body = http.MaxBytesReader(nil, req.Body, limit)
+	}
+	return decoder.Decode(req, dst)
+}
+
+// maxBodySize returns the body limit of the Router serving the request.
+func maxBodySize(req *http.Request) int64 {
+	if r := routerFromContext(req.Context()); r != nil && r.maxBodySize != 0 {
+		return r.maxBodySize
+	}
+	return DefaultMaxBodySize
+}
+
+// bodyError classifies errors common to all decoders, falling back to kind.
+func bodyError(err error, kind BodyErrorKind) *BodyError {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		return &BodyError{Kind: BodyTooLarge, Err: err}
+	case errors.Is(err, io.EOF):
+		return &BodyError{Kind: BodyEmpty, Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BodyError{Kind: BodySyntax, Err: err}
+	default:
+		return &BodyError{Kind: kind, Err: err}
+	}
+}
+
+// jsonBodyError converts an encoding/json error into a BodyError.
+func jsonBodyError(err error) *BodyError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &BodyError{Kind: BodySyntax, Offset: syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &BodyError{Kind: BodyTypeMismatch, Field: typeErr.Field, Offset: typeErr.Offset, Err: err}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return &BodyError{Kind: BodyUnknownField, Field: field, Err: err}
+	default:
+		return bodyError(err, BodyInvalid)
+	}
+}
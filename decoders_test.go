@@ -0,0 +1,161 @@
+package gorouter
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type decodeAddress struct {
+	Zip string `json:"zip"`
+}
+
+type decodeUser struct {
+	Name    string        `json:"name" xml:"name" form:"name"`
+	Age     int           `json:"age" xml:"age" form:"age"`
+	Tags    []string      `json:"tags" xml:"tag" form:"tag"`
+	Address decodeAddress `json:"address" xml:"-" form:"-"`
+}
+
+func newBodyRequest(contentType string, body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func TestDecodeBodyFormats(t *testing.T) {
+	msgpackBody, _ := msgpack.Marshal(map[string]interface{}{"name": "ada", "age": 36})
+	cborBody, _ := cbor.Marshal(map[string]interface{}{"name": "ada", "age": 36})
+
+	tests := []struct {
+		contentType string
+		body        []byte
+	}{
+		{"application/json; charset=utf-8", []byte(`{"name":"ada","age":36}`)},
+		{"application/vnd.api+json", []byte(`{"name":"ada","age":36}`)},
+		{"application/xml", []byte(`<user><name>ada</name><age>36</age></user>`)},
+		{"application/x-www-form-urlencoded", []byte(`name=ada&age=36&extra=ignored`)},
+		{"application/msgpack", msgpackBody},
+		{"application/cbor", cborBody},
+	}
+	for _, tt := range tests {
+		var user decodeUser
+		if err := DecodeBody(newBodyRequest(tt.contentType, tt.body), &user); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.contentType, err)
+			continue
+		}
+		if user.Name != "ada" || user.Age != 36 {
+			t.Errorf("%s: expected ada/36, got %+v", tt.contentType, user)
+		}
+	}
+}
+
+func TestDecodeBodyMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "ada")
+	mw.WriteField("tag", "a")
+	mw.WriteField("tag", "b")
+	part, _ := mw.CreateFormFile("avatar", "avatar.png")
+	part.Write([]byte("png"))
+	mw.Close()
+
+	var dst struct {
+		Name   string                `form:"name"`
+		Tags   []string              `form:"tag"`
+		Avatar *multipart.FileHeader `form:"avatar"`
+	}
+	if err := DecodeBody(newBodyRequest(mw.FormDataContentType(), body.Bytes()), &dst); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dst.Name != "ada" || strings.Join(dst.Tags, ",") != "a,b" || dst.Avatar == nil || dst.Avatar.Filename != "avatar.png" {
+		t.Errorf("Unexpected result: %+v", dst)
+	}
+}
+
+func TestDecodeBodyErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		kind        BodyErrorKind
+		field       string
+		offset      int64
+	}{
+		{"empty", "application/json", "", BodyEmpty, "", 0},
+		{"syntax", "application/json", `{"name":}`, BodySyntax, "", 9},
+		{"truncated", "application/json", `{"name":"ada"`, BodySyntax, "", 0},
+		{"trailing data", "application/json", `{"name":"ada"} {}`, BodySyntax, "", 14},
+		{"type mismatch", "application/json", `{"address":{"zip":5}}`, BodyTypeMismatch, "address.zip", 19},
+		{"unknown field", "application/json", `{"nickname":"a"}`, BodyUnknownField, "nickname", 0},
+		{"form type mismatch", "application/x-www-form-urlencoded", "age=old", BodyTypeMismatch, "age", 0},
+		{"unsupported", "text/plain", "hi", BodyUnsupportedMediaType, "", 0},
+		{"missing content type", "", "hi", BodyUnsupportedMediaType, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user decodeUser
+			err := DecodeBody(newBodyRequest(tt.contentType, []byte(tt.body)), &user)
+			var bodyErr *BodyError
+			if !errors.As(err, &bodyErr) {
+				t.Fatalf("Expected BodyError, got %v", err)
+			}
+			if bodyErr.Kind != tt.kind || bodyErr.Field != tt.field || bodyErr.Offset != tt.offset {
+				t.Errorf("Expected kind=%s field=%q offset=%d, got kind=%s field=%q offset=%d",
+					tt.kind, tt.field, tt.offset, bodyErr.Kind, bodyErr.Field, bodyErr.Offset)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyLimitAndRendering(t *testing.T) {
+	r := NewRouter()
+	r.SetMaxBodySize(16)
+	r.AddRoute(http.MethodPost, "/", Adapt(func(c *Context) error {
+		var user decodeUser
+		if err := c.Bind(&user); err != nil {
+			return err
+		}
+		c.Status(http.StatusCreated)
+		return nil
+	}))
+
+	tests := []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`{"name":"ada"}`, http.StatusCreated, ""},
+		{`{"name":"a very long name"}`, http.StatusRequestEntityTooLarge, "body_too_large"},
+		{`{"age":"x"}`, http.StatusBadRequest, "invalid_field_type"},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, newBodyRequest("application/json", []byte(tt.body)))
+		if recorder.Code != tt.status || !strings.Contains(recorder.Body.String(), tt.code) {
+			t.Errorf("Body %s: expected %d with code %q, got %d %s", tt.body, tt.status, tt.code, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestParseJSONBodyAcceptsCharset(t *testing.T) {
+	var user decodeUser
+	recorder := httptest.NewRecorder()
+	req := newBodyRequest("application/json; charset=utf-8", []byte(`{"name":"ada"}`))
+	if err := ParseJSONBody(recorder, req, &user); err != nil || user.Name != "ada" {
+		t.Errorf("Expected body to be parsed, got %v and %+v", err, user)
+	}
+
+	recorder = httptest.NewRecorder()
+	req = newBodyRequest("text/plain", []byte(`{"name":"ada"}`))
+	if err := ParseJSONBody(recorder, req, &user); err == nil || recorder.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415, got %d", recorder.Code)
+	}
+}
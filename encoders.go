@@ -0,0 +1,222 @@
+package gorouter
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoder encodes response data into a particular media type.
+type Encoder interface {
+	Encode(w io.Writer, data interface{}) error
+}
+
+// EncoderFunc adapts a function to the Encoder interface.
+type EncoderFunc func(w io.Writer, data interface{}) error
+
+// Encode calls f(w, data).
+func (f EncoderFunc) Encode(w io.Writer, data interface{}) error {
+	return f(w, data)
+}
+
+// Built-in encoders registered by default, in order of preference.
+var (
+	// JSONEncoder uses the default JSON configuration; Render uses the one
+	// set with Router.SetJSONConfig.
+	JSONEncoder Encoder = jsonEncoder{}
+
+	// XMLEncoder wraps slices in an <items> root element, so the output is a
+	// single well-formed document.
+	XMLEncoder Encoder = EncoderFunc(encodeXML)
+
+	YAMLEncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+		enc := yaml.NewEncoder(w)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	})
+
+	// MessagePackEncoder uses json struct tags so field names match the JSON output.
+	MessagePackEncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+		enc := msgpack.NewEncoder(w)
+		enc.SetCustomStructTag("json")
+		return enc.Encode(data)
+	})
+
+	CBOREncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+		return cbor.NewEncoder(w).Encode(data)
+	})
+
+	// CSVEncoder encodes CSVMarshalers, [][]string, and slices of structs or
+	// string-keyed maps. Struct columns are named by their csv or json tag.
+	CSVEncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+		records, err := csvRecords(data)
+		if err != nil {
+			return err
+		}
+		return csv.NewWriter(w).WriteAll(records)
+	})
+
+	TextEncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+		if b, ok := data.([]byte); ok {
+			_, err := w.Write(b)
+			return err
+		}
+		_, err := fmt.Fprint(w, data)
+		return err
+	})
+)
+
+// jsonEncoder is the type of JSONEncoder, recognized by Render.
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(w io.Writer, data interface{}) error {
+	return defaultJSONConfig.encode(w, data)
+}
+
+func encodeXML(w io.Writer, data interface{}) error {
+	enc := xml.NewEncoder(w)
+	v := reflect.ValueOf(data)
+	if kind := v.Kind(); kind != reflect.Slice && kind != reflect.Array || v.Type().Elem().Kind() == reflect.Uint8 {
+		return enc.Encode(data)
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: "items"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// CSVMarshaler is implemented by types that encode themselves as CSV records.
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+// csvRecords converts data into CSV records with a header row.
+func csvRecords(data interface{}) ([][]string, error) {
+	switch v := data.(type) {
+	case CSVMarshaler:
+		return v.MarshalCSV()
+	case [][]string:
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("csv: cannot encode %T", data)
+	}
+
+	elemType := rv.Type().Elem()
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	switch {
+	case elemType.Kind() == reflect.Struct:
+		return csvStructRecords(rv, elemType), nil
+	case elemType.Kind() == reflect.Map && elemType.Key().Kind() == reflect.String:
+		return csvMapRecords(rv), nil
+	default:
+		return nil, fmt.Errorf("csv: cannot encode %T", data)
+	}
+}
+
+// csvStructRecords encodes a slice of structs, one column per exported field.
+func csvStructRecords(rv reflect.Value, elemType reflect.Type) [][]string {
+	var header []string
+	var fields []int
+	for i := 0; i < elemType.NumField(); i++ {
+		field := elemType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		for _, tag := range []string{"csv", "json"} {
+			if tagName, _, _ := strings.Cut(field.Tag.Get(tag), ","); tagName != "" {
+				name = tagName
+				break
+			}
+		}
+		if name == "-" {
+			continue
+		}
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+
+	records := [][]string{header}
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		for elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		record := make([]string, len(fields))
+		if elem.IsValid() {
+			for j, field := range fields {
+				record[j] = csvValue(elem.Field(field))
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// csvMapRecords encodes a slice of maps, one column per key in sorted order.
+func csvMapRecords(rv reflect.Value) [][]string {
+	keys := map[string]bool{}
+	for i := 0; i < rv.Len(); i++ {
+		for _, key := range rv.Index(i).MapKeys() {
+			keys[key.String()] = true
+		}
+	}
+	header := make([]string, 0, len(keys))
+	for key := range keys {
+		header = append(header, key)
+	}
+	sort.Strings(header)
+
+	records := [][]string{header}
+	for i := 0; i < rv.Len(); i++ {
+		row := rv.Index(i)
+		record := make([]string, len(header))
+		for j, key := range header {
+			if value := row.MapIndex(reflect.ValueOf(key).Convert(row.Type().Key())); value.IsValid() {
+				record[j] = csvValue(value)
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// csvValue formats a single CSV field; nil values become empty strings.
+func csvValue(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -1,32 +1,22 @@
 package gorouter
 
 import (
-	"log"
 	"net/http"
 )
 
-// ErrorHandler is a middleware for handling errors and returning appropriate responses.
+// ErrorHandler is a middleware that recovers from panics, logs them with their
+// stack trace and renders a 500 response through the router's error renderer.
+// Use Recoverer to configure reporting.
 func ErrorHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("panic: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-		}()
-		next.ServeHTTP(w, req)
-	})
+	return defaultRecoverer(next)
 }
 
+var defaultRecoverer = Recoverer(RecovererConfig{})
+
 // CustomErrorHandler is a middleware for custom error handling.
+//
+// Deprecated: CustomErrorHandler duplicated ErrorHandler; use ErrorHandler and
+// Router.SetErrorRenderer to customize the response.
 func CustomErrorHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("panic: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-		}()
-		next.ServeHTTP(w, req)
-	})
+	return ErrorHandler(next)
 }
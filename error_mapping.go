@@ -0,0 +1,82 @@
+package gorouter
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// ErrorMapping describes the response and logging for a class of domain errors.
+type ErrorMapping struct {
+	Status  int          // HTTP status code
+	Code    string       // Application-specific error code
+	Type    string       // Problem type URI used by ProblemErrorRenderer
+	Message string       // Client-facing message; defaults to the status text
+	Expose  bool         // Use the error's own text as the client-facing message
+	Level   slog.Leveler // Level at which matched errors are logged; defaults to Error for 5xx statuses and Debug otherwise
+}
+
+// errorMatcher pairs a match function with its mapping.
+type errorMatcher struct {
+	match   func(err error) bool
+	mapping ErrorMapping
+}
+
+// MapError maps errors matching target with errors.Is to the given response,
+// so domain packages can return sentinel errors such as sql.ErrNoRows.
+// Mappings are consulted in registration order and the first match wins.
+func (r *Router) MapError(target error, mapping ErrorMapping) {
+	r.MapErrorFunc(func(err error) bool {
+		return errors.Is(err, target)
+	}, mapping)
+}
+
+// MapErrorFunc maps errors for which match returns true to the given response.
+func (r *Router) MapErrorFunc(match func(err error) bool, mapping ErrorMapping) {
+	r.errorMappings = append(r.errorMappings, errorMatcher{match: match, mapping: mapping})
+}
+
+// MapErrorAs maps errors whose chain contains an error of type T, as found by
+// errors.As, to the given response.
+func MapErrorAs[T error](r *Router, mapping ErrorMapping) {
+	r.MapErrorFunc(func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}, mapping)
+}
+
+// mapError returns the HTTPError and log level registered for err, if any.
+// Errors that already are HTTPErrors or Problems are left to the renderer.
+func (r *Router) mapError(err error) (*HTTPError, slog.Level, bool) {
+	var httpErr *HTTPError
+	var problem *Problem
+	if errors.As(err, &httpErr) || errors.As(err, &problem) {
+		return nil, 0, false
+	}
+
+	for _, matcher := range r.errorMappings {
+		if !matcher.match(err) {
+			continue
+		}
+		mapping := matcher.mapping
+		status := mapping.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		message := mapping.Message
+		if mapping.Expose {
+			message = err.Error()
+		}
+		httpErr := NewHTTPError(status, message).Wrap(err)
+		httpErr.Code = mapping.Code
+		httpErr.Type = mapping.Type
+		level := slog.LevelDebug
+		if mapping.Level != nil {
+			level = mapping.Level.Level()
+		} else if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		return httpErr, level, true
+	}
+	return nil, 0, false
+}
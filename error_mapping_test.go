@@ -0,0 +1,96 @@
+package gorouter
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type notFoundError struct {
+	resource string
+}
+
+func (e *notFoundError) Error() string {
+	return e.resource + " does not exist"
+}
+
+func TestErrorMapping(t *testing.T) {
+	r := NewRouter()
+	r.MapError(sql.ErrNoRows, ErrorMapping{Status: http.StatusNotFound, Code: "not_found"})
+	MapErrorAs[*notFoundError](r, ErrorMapping{Status: http.StatusNotFound, Expose: true, Type: "https://example.com/probs/missing"})
+	r.MapErrorFunc(func(err error) bool {
+		return err.Error() == "conflict"
+	}, ErrorMapping{Status: http.StatusConflict})
+
+	r.AddRoute(http.MethodGet, "/row", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return fmt.Errorf("loading user: %w", sql.ErrNoRows)
+	}))
+	r.AddRoute(http.MethodGet, "/order", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return &notFoundError{resource: "order 7"}
+	}))
+	r.AddRoute(http.MethodGet, "/conflict", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return errors.New("conflict")
+	}))
+	r.AddRoute(http.MethodGet, "/explicit", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return NewHTTPError(http.StatusGone, "").Wrap(sql.ErrNoRows)
+	}))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/row", http.StatusNotFound, `{"code":"not_found","error":"Not Found"}` + "\n"},
+		{"/order", http.StatusNotFound, `{"error":"order 7 does not exist"}` + "\n"},
+		{"/conflict", http.StatusConflict, `{"error":"Conflict"}` + "\n"},
+		{"/explicit", http.StatusGone, `{"error":"Gone"}` + "\n"},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		checkResponse(t, recorder, tt.code, tt.body, "", "")
+	}
+}
+
+func TestErrorMappingProblemType(t *testing.T) {
+	r := NewRouter()
+	r.SetErrorRenderer(ProblemErrorRenderer)
+	MapErrorAs[*notFoundError](r, ErrorMapping{Status: http.StatusNotFound, Type: "https://example.com/probs/missing"})
+	r.AddRoute(http.MethodGet, "/order", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return &notFoundError{resource: "order 7"}
+	}))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/order", nil))
+	problem := decodeProblem(t, recorder)
+	if problem.Type != "https://example.com/probs/missing" || problem.Status != http.StatusNotFound {
+		t.Errorf("Expected mapped problem type and status, got %s %d", problem.Type, problem.Status)
+	}
+}
+
+func TestErrorMappingLevels(t *testing.T) {
+	logger, buf := newBufferLogger()
+	r := NewRouter()
+	r.SetLogger(logger)
+	errUnavailable, errConflict := errors.New("unavailable"), errors.New("conflict")
+	r.MapError(sql.ErrNoRows, ErrorMapping{Status: http.StatusNotFound})
+	r.MapError(errUnavailable, ErrorMapping{Status: http.StatusServiceUnavailable})
+	r.MapError(errConflict, ErrorMapping{Status: http.StatusConflict, Level: slog.LevelWarn})
+	for path, err := range map[string]error{"/missing": sql.ErrNoRows, "/down": errUnavailable, "/conflict": errConflict} {
+		r.AddRoute(http.MethodGet, path, Adapt(func(w http.ResponseWriter, req *http.Request) error {
+			return err
+		}))
+	}
+
+	for _, path := range []string{"/missing", "/down", "/conflict"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	records := decodeRecords(t, buf)
+	if len(records) != 2 || records[0]["level"] != "ERROR" || records[1]["level"] != "WARN" {
+		t.Errorf("Expected 4xx errors to be logged at Debug unless configured, got %v", records)
+	}
+}
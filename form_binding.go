@@ -0,0 +1,160 @@
+package gorouter
+
+import (
+	"encoding"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindValues copies form values and files into dst, which must be a pointer to
+// url.Values, map[string][]string, map[string]string or a struct. Struct fields
+// are matched by their form tag, then json tag, then name, and may be strings,
+// booleans, numbers, encoding.TextUnmarshalers, slices or pointers of those,
+// *multipart.FileHeader or []*multipart.FileHeader. Values without a matching
+// field are ignored.
+func BindValues(values map[string][]string, files map[string][]*multipart.FileHeader, dst interface{}) error {
+	switch d := dst.(type) {
+	case *url.Values:
+		*d = url.Values(values)
+		return nil
+	case *map[string][]string:
+		*d = values
+		return nil
+	case *map[string]string:
+		*d = make(map[string]string, len(values))
+		for key, vals := range values {
+			if len(vals) > 0 {
+				(*d)[key] = vals[0]
+			}
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &BodyError{Kind: BodyInvalid, Err: fmt.Errorf("cannot bind form values into %T", dst)}
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := formFieldName(field)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+
+		switch field.Type {
+		case fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case fileHeaderSliceType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setFormField(fv, vals); err != nil {
+			return &BodyError{Kind: BodyTypeMismatch, Field: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// formFieldName returns the form key of a struct field.
+func formFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"form", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// setFormField sets a struct field from its form values.
+func setFormField(fv reflect.Value, vals []string) error {
+	if fv.Kind() == reflect.Slice && !fv.Addr().Type().Implements(textUnmarshalerType) && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setFormValue(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setFormValue(fv, vals[0])
+}
+
+// setFormValue parses a single form value into v.
+func setFormValue(v reflect.Value, val string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setFormValue(ptr.Elem(), val); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported field type %s", v.Type())
+		}
+		v.SetBytes([]byte(val))
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
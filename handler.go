@@ -0,0 +1,61 @@
+package gorouter
+
+import (
+	"net/http"
+)
+
+// Handler is the constraint satisfied by the handler signatures Adapt accepts:
+//   - http.HandlerFunc or func(http.ResponseWriter, *http.Request)
+//   - ContextHandler or func(*Context)
+//   - ErrorHandlerFunc or func(http.ResponseWriter, *http.Request) error
+//   - func(*Context) error
+type Handler interface {
+	http.HandlerFunc | func(http.ResponseWriter, *http.Request) |
+		ContextHandler | func(*Context) |
+		ErrorHandlerFunc | func(http.ResponseWriter, *http.Request) error |
+		func(*Context) error
+}
+
+// Adapt converts a handler of any supported signature into an
+// http.HandlerFunc, for registration with Router and RouteGroup:
+//
+//	r.AddRoute(http.MethodGet, "/users/:id", gorouter.Adapt(func(c *gorouter.Context) error {
+//		...
+//	}))
+//
+// Other signatures fail to compile. Register an http.Handler with its
+// ServeHTTP method.
+func Adapt[H Handler](handler H) http.HandlerFunc {
+	switch h := any(handler).(type) {
+	case http.HandlerFunc:
+		return h
+	case func(http.ResponseWriter, *http.Request):
+		return h
+	case ContextHandler:
+		return h.ServeHTTP
+	case func(*Context):
+		return ContextHandler(h).ServeHTTP
+	case ErrorHandlerFunc:
+		return h.ServeHTTP
+	case func(http.ResponseWriter, *http.Request) error:
+		return ErrorHandlerFunc(h).ServeHTTP
+	case func(*Context) error:
+		return ContextHandler(func(c *Context) {
+			if err := h(c); err != nil {
+				RenderError(c.Writer, c.Request, err)
+			}
+		}).ServeHTTP
+	}
+	panic("unreachable")
+}
+
+// ErrorHandlerFunc is a handler that returns an error instead of writing it.
+// Returned errors are rendered by the Router's error renderer.
+type ErrorHandlerFunc func(w http.ResponseWriter, req *http.Request) error
+
+// ServeHTTP implements the http.Handler interface for ErrorHandlerFunc.
+func (h ErrorHandlerFunc) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := h(w, req); err != nil {
+		RenderError(w, req, err)
+	}
+}
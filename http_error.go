@@ -0,0 +1,177 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/saadi925/gorouter/validation"
+)
+
+// HTTPError is an error carrying the HTTP response that should be sent for it.
+type HTTPError struct {
+	Status  int         // HTTP status code
+	Code    string      // Application-specific error code
+	Type    string      // Problem type URI used by ProblemErrorRenderer
+	Message string      // Message safe to show to clients
+	Details interface{} // Optional structured details
+	Cause   error       // Underlying error, never sent to clients
+}
+
+// NewHTTPError creates an HTTPError. An empty message defaults to the status text.
+func NewHTTPError(status int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &HTTPError{Status: status, Message: message}
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// Unwrap returns the underlying cause.
+func (e *HTTPError) Unwrap() error {
+	return e.Cause
+}
+
+// WithCode returns a copy of the error with the given application code.
+func (e *HTTPError) WithCode(code string) *HTTPError {
+	clone := *e
+	clone.Code = code
+	return &clone
+}
+
+// WithDetails returns a copy of the error with the given details.
+func (e *HTTPError) WithDetails(details interface{}) *HTTPError {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// Wrap returns a copy of the error with the given cause.
+func (e *HTTPError) Wrap(cause error) *HTTPError {
+	clone := *e
+	clone.Cause = cause
+	return &clone
+}
+
+// ErrorRenderer converts an error returned by a handler into a response.
+type ErrorRenderer func(w http.ResponseWriter, req *http.Request, err error)
+
+// SetErrorRenderer sets the renderer used for errors returned by handlers.
+func (r *Router) SetErrorRenderer(renderer ErrorRenderer) {
+	r.errorRenderer = renderer
+}
+
+// routerKey is the context key under which the Router serving a request is stored.
+type routerKey struct{}
+
+// routerFromContext returns the Router serving the request, if any.
+func routerFromContext(ctx context.Context) *Router {
+	r, _ := ctx.Value(routerKey{}).(*Router)
+	return r
+}
+
+// RenderError writes the response for err using the error renderer of the
+// Router serving the request, or DefaultErrorRenderer outside a Router.
+// Errors registered with Router.MapError are converted to HTTPErrors first.
+// Server errors and mapped errors are logged, and nothing is written if the
+// response has already been started.
+func RenderError(w http.ResponseWriter, req *http.Request, err error) {
+	r := routerFromContext(req.Context())
+	level, shouldLog := slog.LevelError, errorStatus(err) >= http.StatusInternalServerError
+	if r != nil {
+		if mapped, mappedLevel, ok := r.mapError(err); ok {
+			err, level, shouldLog = mapped, mappedLevel, true
+		}
+	}
+	// Panics have already been reported by the Recoverer.
+	if shouldLog && !errors.As(err, new(*PanicError)) {
+		logRequestError(req, err, level)
+	}
+
+	if rw, ok := w.(ResponseWriter); ok && rw.Written() {
+		LoggerFrom(req.Context()).Log(req.Context(), slog.LevelError, "Error after response was started", "error", err, "url", req.URL.Path)
+		return
+	}
+	writeError(w, req, err)
+}
+
+// writeError writes the response for err with the Router's error renderer,
+// without logging it.
+func writeError(w http.ResponseWriter, req *http.Request, err error) {
+	r := routerFromContext(req.Context())
+	renderer := DefaultErrorRenderer
+	if r != nil && r.errorRenderer != nil {
+		renderer = r.errorRenderer
+	}
+	renderer(w, req, err)
+}
+
+// DefaultErrorRenderer writes HTTPErrors and validation failures as JSON, writes
+// returned Problems as problem details and hides all other errors behind a
+// generic 500 response.
+func DefaultErrorRenderer(w http.ResponseWriter, req *http.Request, err error) {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		WriteProblem(w, problem)
+		return
+	}
+
+	httpErr := toHTTPError(err)
+	body := map[string]interface{}{"error": httpErr.Message}
+	if httpErr.Code != "" {
+		body["code"] = httpErr.Code
+	}
+	if httpErr.Details != nil {
+		body["details"] = httpErr.Details
+	}
+	WriteJSON(w, req, body, httpErr.Status)
+}
+
+// logRequestError logs an error returned while serving a request, masked
+// with the request's RedactionPolicy.
+func logRequestError(req *http.Request, err error, level slog.Level) {
+	LoggerFrom(req.Context()).Log(req.Context(), level, "Request failed",
+		"error", RedactionFrom(req.Context()).String(err.Error()),
+		"method", req.Method,
+		"url", req.URL.Path,
+	)
+}
+
+// errorStatus returns the status code an error is rendered with by default.
+func errorStatus(err error) int {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		if problem.Status == 0 {
+			return http.StatusInternalServerError
+		}
+		return problem.Status
+	}
+	return toHTTPError(err).Status
+}
+
+// toHTTPError returns the HTTPError in err's chain, the HTTPError for body
+// decoding and validation failures, or a 500 HTTPError wrapping err.
+func toHTTPError(err error) *HTTPError {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	var bodyErr *BodyError
+	if errors.As(err, &bodyErr) {
+		return bodyErr.httpError()
+	}
+	var validationErrors validation.Errors
+	if errors.As(err, &validationErrors) {
+		return NewHTTPError(http.StatusUnprocessableEntity, "Validation failed").WithDetails(invalidParams(validationErrors)).Wrap(err)
+	}
+	return NewHTTPError(http.StatusInternalServerError, "").Wrap(err)
+}
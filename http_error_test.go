@@ -0,0 +1,74 @@
+package gorouter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorHandlerFunc(t *testing.T) {
+	r := NewRouter()
+	r.AddRoute(http.MethodGet, "/missing", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return NewHTTPError(http.StatusNotFound, "user not found").WithCode("user_not_found")
+	}))
+	r.AddRoute(http.MethodGet, "/broken", Adapt(func(c *Context) error {
+		return errors.New("database is down")
+	}))
+	r.AddRoute(http.MethodGet, "/partial", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("started"))
+		return errors.New("failed mid-response")
+	}))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	checkResponse(t, recorder, http.StatusNotFound, `{"code":"user_not_found","error":"user not found"}`+"\n", "Content-Type", "application/json")
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	checkResponse(t, recorder, http.StatusInternalServerError, `{"error":"Internal Server Error"}`+"\n", "", "")
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	checkResponse(t, recorder, http.StatusAccepted, "started", "", "")
+}
+
+func TestCustomErrorRenderer(t *testing.T) {
+	r := NewRouter()
+	r.SetErrorRenderer(func(w http.ResponseWriter, req *http.Request, err error) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(err.Error()))
+	})
+	r.Use(ErrorHandler)
+	r.AddRoute(http.MethodGet, "/error", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return errors.New("boom")
+	}))
+	r.AddRoute(http.MethodGet, "/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("oops")
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/error", nil))
+	checkResponse(t, recorder, http.StatusTeapot, "boom", "", "")
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	checkResponse(t, recorder, http.StatusTeapot, "500 Internal Server Error: panic: oops", "", "")
+}
+
+func TestHTTPErrorUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+	base := NewHTTPError(http.StatusBadRequest, "")
+	err := base.Wrap(cause)
+
+	if !errors.Is(err, cause) {
+		t.Error("Expected HTTPError to unwrap to its cause")
+	}
+	if base.Cause != nil {
+		t.Error("Expected Wrap to leave the original error unchanged")
+	}
+	if err.Message != "Bad Request" {
+		t.Errorf("Expected default message %q, got %q", "Bad Request", err.Message)
+	}
+}
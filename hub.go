@@ -0,0 +1,464 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// DefaultHubQueueSize is the per-client queue size used when HubOptions leaves it unset.
+const DefaultHubQueueSize = 64
+
+// ErrSlowConsumer is reported by HubClient.Err when the client was evicted
+// because its queue was full.
+var ErrSlowConsumer = errors.New("gorouter: slow consumer evicted")
+
+// ErrHubClosed is returned when publishing to a closed Hub.
+var ErrHubClosed = errors.New("gorouter: hub closed")
+
+// Message is a payload published to a hub topic.
+type Message struct {
+	Topic string `json:"topic"`
+	ID    string `json:"id,omitempty"`    // Sent as the SSE event ID
+	Event string `json:"event,omitempty"` // Sent as the SSE event type
+	Data  []byte `json:"data"`
+}
+
+// SlowConsumerPolicy decides what happens when a client's queue is full.
+type SlowConsumerPolicy int
+
+const (
+	DropOldest SlowConsumerPolicy = iota // Discard the oldest queued message
+	DropNewest                           // Discard the message being delivered
+	Evict                                // Close the client with ErrSlowConsumer
+)
+
+// Backplane distributes published messages to every Hub subscribed to it, so
+// hubs in several router instances can share topics.
+type Backplane interface {
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe registers a function called for every published message and
+	// returns a function removing it.
+	Subscribe(handler func(Message)) (unsubscribe func(), err error)
+}
+
+// MemoryBackplane is a Backplane delivering messages within the process.
+type MemoryBackplane struct {
+	mu       sync.RWMutex
+	handlers map[int]func(Message)
+	next     int
+}
+
+// NewMemoryBackplane creates a MemoryBackplane.
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{handlers: make(map[int]func(Message))}
+}
+
+// Publish calls every subscribed handler with msg. Handlers are called
+// without the lock held, so they may subscribe and unsubscribe.
+func (b *MemoryBackplane) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	handlers := make([]func(Message), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(msg)
+	}
+	return nil
+}
+
+// Subscribe registers handler until the returned function is called.
+func (b *MemoryBackplane) Subscribe(handler func(Message)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
+
+// HubOptions represents configuration options for a Hub.
+type HubOptions struct {
+	QueueSize    int                                                // Messages buffered per client; defaults to DefaultHubQueueSize
+	SlowConsumer SlowConsumerPolicy                                 // Applied when a client's queue is full
+	Backplane    Backplane                                          // Shared message bus; defaults to a private MemoryBackplane
+	ClientID     func(req *http.Request) string                     // Identifies clients of the SSE and WebSocket adapters
+	OnPresence   func(topic string, client ClientInfo, joined bool) // Called when clients join or leave a topic
+}
+
+// ClientInfo describes a client for presence tracking.
+type ClientInfo struct {
+	ID   string            `json:"id"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Hub fans out messages published to topics to the clients subscribed to them.
+// Presence is tracked per Hub; the Backplane only shares messages.
+type Hub struct {
+	opts        HubOptions
+	mu          sync.RWMutex
+	topics      map[string]map[*HubClient]struct{}
+	clients     map[*HubClient]struct{}
+	closed      bool
+	unsubscribe func()
+	nextID      atomic.Int64
+}
+
+// NewHub creates a Hub and subscribes it to the backplane.
+func NewHub(opts HubOptions) (*Hub, error) {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultHubQueueSize
+	}
+	if opts.Backplane == nil {
+		opts.Backplane = NewMemoryBackplane()
+	}
+	h := &Hub{
+		opts:    opts,
+		topics:  make(map[string]map[*HubClient]struct{}),
+		clients: make(map[*HubClient]struct{}),
+	}
+	unsubscribe, err := opts.Backplane.Subscribe(h.deliver)
+	if err != nil {
+		return nil, err
+	}
+	h.unsubscribe = unsubscribe
+	return h, nil
+}
+
+// Register adds a client to the hub. It receives nothing until it subscribes to topics.
+func (h *Hub) Register(id string, meta map[string]string) *HubClient {
+	c := &HubClient{
+		info:   ClientInfo{ID: id, Meta: meta},
+		hub:    h,
+		send:   make(chan Message, h.opts.QueueSize),
+		topics: make(map[string]bool),
+		done:   make(chan struct{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		c.close(ErrHubClosed)
+		return c
+	}
+	h.clients[c] = struct{}{}
+	return c
+}
+
+// Publish sends data to every client subscribed to topic, in all hubs sharing the backplane.
+func (h *Hub) Publish(ctx context.Context, topic string, data []byte) error {
+	return h.PublishMessage(ctx, Message{Topic: topic, Data: data})
+}
+
+// PublishMessage sends msg to every client subscribed to msg.Topic, in all hubs sharing the backplane.
+func (h *Hub) PublishMessage(ctx context.Context, msg Message) error {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		return ErrHubClosed
+	}
+	return h.opts.Backplane.Publish(ctx, msg)
+}
+
+// Presence returns the clients subscribed to topic, sorted by ID.
+func (h *Hub) Presence(topic string) []ClientInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	infos := make([]ClientInfo, 0, len(h.topics[topic]))
+	for c := range h.topics[topic] {
+		infos = append(infos, c.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// Topics returns the topics with at least one subscriber, sorted.
+func (h *Hub) Topics() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	topics := make([]string, 0, len(h.topics))
+	for topic := range h.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Close unsubscribes the hub from the backplane and closes all clients.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	clients := make([]*HubClient, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	h.unsubscribe()
+	for _, c := range clients {
+		c.remove(ErrHubClosed)
+	}
+}
+
+// deliver queues a message for the local subscribers of its topic.
+func (h *Hub) deliver(msg Message) {
+	var evicted []*HubClient
+	h.mu.RLock()
+	for c := range h.topics[msg.Topic] {
+		if !c.enqueue(msg, h.opts.SlowConsumer) {
+			evicted = append(evicted, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range evicted {
+		c.remove(ErrSlowConsumer)
+	}
+}
+
+// HubClient is a connection registered with a Hub.
+type HubClient struct {
+	info   ClientInfo
+	hub    *Hub
+	send   chan Message
+	topics map[string]bool // guarded by hub.mu
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// Info returns the client's ID and metadata.
+func (c *HubClient) Info() ClientInfo {
+	return c.info
+}
+
+// Subscribe adds the client to topics.
+func (c *HubClient) Subscribe(topics ...string) {
+	var joined []string
+	c.hub.mu.Lock()
+	if _, ok := c.hub.clients[c]; ok {
+		for _, topic := range topics {
+			if c.topics[topic] {
+				continue
+			}
+			c.topics[topic] = true
+			if c.hub.topics[topic] == nil {
+				c.hub.topics[topic] = make(map[*HubClient]struct{})
+			}
+			c.hub.topics[topic][c] = struct{}{}
+			joined = append(joined, topic)
+		}
+	}
+	c.hub.mu.Unlock()
+	c.hub.notifyPresence(joined, c.info, true)
+}
+
+// Unsubscribe removes the client from topics.
+func (c *HubClient) Unsubscribe(topics ...string) {
+	c.hub.mu.Lock()
+	left := c.leave(topics)
+	c.hub.mu.Unlock()
+	c.hub.notifyPresence(left, c.info, false)
+}
+
+// Messages returns the channel messages are delivered on. It is never closed;
+// select on Done as well.
+func (c *HubClient) Messages() <-chan Message {
+	return c.send
+}
+
+// Done is closed when the client is closed or evicted.
+func (c *HubClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the client was closed: nil after Close, ErrSlowConsumer or
+// ErrHubClosed otherwise. It returns nil while the client is open.
+func (c *HubClient) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close unsubscribes the client from all topics and removes it from the hub.
+func (c *HubClient) Close() {
+	c.remove(nil)
+}
+
+// remove detaches the client from the hub and closes it with err.
+func (c *HubClient) remove(err error) {
+	c.hub.mu.Lock()
+	topics := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		topics = append(topics, topic)
+	}
+	left := c.leave(topics)
+	delete(c.hub.clients, c)
+	c.hub.mu.Unlock()
+
+	c.close(err)
+	c.hub.notifyPresence(left, c.info, false)
+}
+
+// leave removes the client from topics and returns those it was in. The
+// caller must hold hub.mu.
+func (c *HubClient) leave(topics []string) []string {
+	var left []string
+	for _, topic := range topics {
+		if !c.topics[topic] {
+			continue
+		}
+		delete(c.topics, topic)
+		delete(c.hub.topics[topic], c)
+		if len(c.hub.topics[topic]) == 0 {
+			delete(c.hub.topics, topic)
+		}
+		left = append(left, topic)
+	}
+	sort.Strings(left)
+	return left
+}
+
+// close marks the client as done.
+func (c *HubClient) close(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+// enqueue queues msg according to the slow consumer policy. It returns false
+// if the client must be evicted.
+func (c *HubClient) enqueue(msg Message, policy SlowConsumerPolicy) bool {
+	select {
+	case c.send <- msg:
+		return true
+	default:
+	}
+
+	switch policy {
+	case DropNewest:
+		return true
+	case Evict:
+		return false
+	default:
+		select {
+		case <-c.send:
+		default:
+		}
+		select {
+		case c.send <- msg:
+		default:
+		}
+		return true
+	}
+}
+
+// notifyPresence calls the OnPresence hook for each topic.
+func (h *Hub) notifyPresence(topics []string, info ClientInfo, joined bool) {
+	if h.opts.OnPresence == nil {
+		return
+	}
+	for _, topic := range topics {
+		h.opts.OnPresence(topic, info, joined)
+	}
+}
+
+// registerRequest registers a client for an adapter request.
+func (h *Hub) registerRequest(req *http.Request) *HubClient {
+	id := ""
+	if h.opts.ClientID != nil {
+		id = h.opts.ClientID(req)
+	}
+	if id == "" {
+		id = strconv.FormatInt(h.nextID.Add(1), 10)
+	}
+	return h.Register(id, nil)
+}
+
+// SSE returns an SSEHandlerFunc subscribing each stream to the topics
+// returned by topics and forwarding their messages as events. The stream ends
+// when the client is evicted, so it can reconnect and resume.
+func (h *Hub) SSE(topics func(req *http.Request) []string) SSEHandlerFunc {
+	return func(stream *SSEStream, req *http.Request) error {
+		client := h.registerRequest(req)
+		defer client.Close()
+		client.Subscribe(topics(req)...)
+
+		for {
+			select {
+			case msg := <-client.Messages():
+				if err := stream.Send(Event{ID: msg.ID, Event: msg.Event, Data: msg.Data}); err != nil {
+					return err
+				}
+			case <-client.Done():
+				return nil
+			case <-stream.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// WebSocket returns a WebSocketHandlerFunc subscribing each connection to the
+// topics returned by topics and forwarding their messages, as text if valid
+// UTF-8 and as binary otherwise. Messages from the client are discarded.
+// Evicted clients are closed with ClosePolicyViolation.
+func (h *Hub) WebSocket(topics func(req *http.Request) []string) WebSocketHandlerFunc {
+	return func(conn *WebSocketConn, req *http.Request) error {
+		client := h.registerRequest(req)
+		defer client.Close()
+		client.Subscribe(topics(req)...)
+
+		readErr := make(chan error, 1)
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					readErr <- err
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case msg := <-client.Messages():
+				messageType := TextMessage
+				if !utf8.Valid(msg.Data) {
+					messageType = BinaryMessage
+				}
+				if err := conn.WriteMessage(messageType, msg.Data); err != nil {
+					return err
+				}
+			case <-client.Done():
+				if client.Err() == ErrSlowConsumer {
+					conn.CloseWithCode(ClosePolicyViolation, "slow consumer")
+				} else {
+					conn.CloseWithCode(CloseGoingAway, "")
+				}
+				return nil
+			case err := <-readErr:
+				return err
+			}
+		}
+	}
+}
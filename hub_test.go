@@ -0,0 +1,227 @@
+package gorouter
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHub(t *testing.T, opts HubOptions) *Hub {
+	t.Helper()
+	hub, err := NewHub(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(hub.Close)
+	return hub
+}
+
+// drain returns the data of the messages queued for client.
+func drain(client *HubClient) []string {
+	var data []string
+	for {
+		select {
+		case msg := <-client.Messages():
+			data = append(data, string(msg.Data))
+		default:
+			return data
+		}
+	}
+}
+
+func TestHubFanOut(t *testing.T) {
+	hub := newTestHub(t, HubOptions{})
+	a := hub.Register("a", nil)
+	b := hub.Register("b", nil)
+	a.Subscribe("news", "sports")
+	b.Subscribe("news")
+
+	ctx := context.Background()
+	hub.Publish(ctx, "news", []byte("n1"))
+	hub.Publish(ctx, "sports", []byte("s1"))
+	hub.Publish(ctx, "weather", []byte("w1"))
+
+	if got := drain(a); !reflect.DeepEqual(got, []string{"n1", "s1"}) {
+		t.Errorf("Expected a to receive n1 and s1, got %v", got)
+	}
+	if got := drain(b); !reflect.DeepEqual(got, []string{"n1"}) {
+		t.Errorf("Expected b to receive n1, got %v", got)
+	}
+
+	b.Unsubscribe("news")
+	hub.Publish(ctx, "news", []byte("n2"))
+	if got := drain(b); len(got) != 0 {
+		t.Errorf("Expected no messages after unsubscribing, got %v", got)
+	}
+}
+
+func TestHubSlowConsumerPolicies(t *testing.T) {
+	tests := []struct {
+		policy  SlowConsumerPolicy
+		want    []string
+		evicted bool
+	}{
+		{DropOldest, []string{"2", "3"}, false},
+		{DropNewest, []string{"1", "2"}, false},
+		{Evict, []string{"1", "2"}, true},
+	}
+	for _, tt := range tests {
+		hub := newTestHub(t, HubOptions{QueueSize: 2, SlowConsumer: tt.policy})
+		client := hub.Register("slow", nil)
+		client.Subscribe("t")
+		for _, data := range []string{"1", "2", "3"} {
+			hub.Publish(context.Background(), "t", []byte(data))
+		}
+
+		if got := drain(client); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Policy %d: expected %v, got %v", tt.policy, tt.want, got)
+		}
+		if evicted := client.Err() == ErrSlowConsumer; evicted != tt.evicted {
+			t.Errorf("Policy %d: expected evicted=%v, got err %v", tt.policy, tt.evicted, client.Err())
+		}
+		if tt.evicted && len(hub.Presence("t")) != 0 {
+			t.Errorf("Policy %d: expected evicted client to leave the topic", tt.policy)
+		}
+	}
+}
+
+func TestHubPresence(t *testing.T) {
+	var events []string
+	hub := newTestHub(t, HubOptions{OnPresence: func(topic string, client ClientInfo, joined bool) {
+		action := "leave"
+		if joined {
+			action = "join"
+		}
+		events = append(events, topic+":"+client.ID+":"+action)
+	}})
+
+	b := hub.Register("b", map[string]string{"name": "Bob"})
+	a := hub.Register("a", nil)
+	a.Subscribe("room")
+	b.Subscribe("room", "lobby")
+
+	want := []ClientInfo{{ID: "a"}, {ID: "b", Meta: map[string]string{"name": "Bob"}}}
+	if got := hub.Presence("room"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected presence %v, got %v", want, got)
+	}
+	if got := hub.Topics(); !reflect.DeepEqual(got, []string{"lobby", "room"}) {
+		t.Errorf("Expected topics lobby and room, got %v", got)
+	}
+
+	b.Close()
+	if got := hub.Presence("room"); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("Expected only a after b closed, got %v", got)
+	}
+	if got := hub.Topics(); !reflect.DeepEqual(got, []string{"room"}) {
+		t.Errorf("Expected empty topics to be removed, got %v", got)
+	}
+
+	wantEvents := []string{"room:a:join", "room:b:join", "lobby:b:join", "lobby:b:leave", "room:b:leave"}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("Expected presence events %v, got %v", wantEvents, events)
+	}
+}
+
+func TestHubSharedBackplane(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	first := newTestHub(t, HubOptions{Backplane: backplane})
+	second := newTestHub(t, HubOptions{Backplane: backplane})
+
+	client := second.Register("remote", nil)
+	client.Subscribe("t")
+	first.Publish(context.Background(), "t", []byte("hello"))
+
+	if got := drain(client); !reflect.DeepEqual(got, []string{"hello"}) {
+		t.Errorf("Expected message through the backplane, got %v", got)
+	}
+
+	first.Close()
+	if err := first.Publish(context.Background(), "t", nil); err != ErrHubClosed {
+		t.Errorf("Expected ErrHubClosed, got %v", err)
+	}
+}
+
+// waitForPresence waits until topic has n subscribers.
+func waitForPresence(t *testing.T, hub *Hub, topic string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(hub.Presence(topic)) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d subscribers to %s, got %d", n, topic, len(hub.Presence(topic)))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHubAdapters(t *testing.T) {
+	hub := newTestHub(t, HubOptions{ClientID: func(req *http.Request) string { return req.URL.Query().Get("id") }})
+	topics := func(req *http.Request) []string { return []string{req.URL.Query().Get("topic")} }
+
+	router := NewRouter()
+	group := router.Group("")
+	group.SSE("/events", SSEOptions{Heartbeat: -1}, hub.SSE(topics))
+	group.WS("/ws", WebSocketOptions{}, hub.WebSocket(topics))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?id=sse&topic=chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	conn := dialTestPath(t, server, "/ws?id=ws&topic=chat")
+
+	waitForPresence(t, hub, "chat", 2)
+	hub.PublishMessage(context.Background(), Message{Topic: "chat", ID: "7", Event: "say", Data: []byte("hi")})
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if got := strings.Join(lines, "|"); got != "id: 7|event: say|data: hi" {
+		t.Errorf("Unexpected SSE event %q", got)
+	}
+
+	messageType, data, err := conn.ReadMessage()
+	if err != nil || messageType != TextMessage || string(data) != "hi" {
+		t.Errorf("Expected WebSocket text message hi, got %d %q %v", messageType, data, err)
+	}
+
+	conn.Close()
+	waitForPresence(t, hub, "chat", 1)
+}
+
+func TestMemoryBackplaneHandlerUnsubscribes(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	var unsubscribe func()
+	calls := 0
+	unsubscribe, _ = backplane.Subscribe(func(msg Message) {
+		calls++
+		unsubscribe()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		backplane.Publish(context.Background(), Message{Topic: "t"})
+		backplane.Publish(context.Background(), Message{Topic: "t"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a handler to be able to unsubscribe during Publish")
+	}
+	if calls != 1 {
+		t.Errorf("Expected the handler to be called once, got %d", calls)
+	}
+}
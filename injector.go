@@ -3,7 +3,9 @@ package gorouter
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
 )
 
 // DependencyRegistry is responsible for managing application dependencies.
@@ -43,3 +45,17 @@ func GetDependency(ctx context.Context, key string) (interface{}, error) {
 	}
 	return val, nil
 }
+
+// Resolve retrieves a dependency from the context and asserts it to type T.
+func Resolve[T any](ctx context.Context, key string) (T, error) {
+	var zero T
+	val, err := GetDependency(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+	dep, ok := val.(T)
+	if !ok {
+		return zero, fmt.Errorf("dependency %q has type %T, not %s", key, val, reflect.TypeFor[T]())
+	}
+	return dep, nil
+}
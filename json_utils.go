@@ -1,20 +1,142 @@
 package gorouter
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 )
 
-// JSONResponse sends a JSON response with the given data and status code.
+// JSONEngine encodes values as JSON, allowing faster encoders to replace encoding/json.
+type JSONEngine interface {
+	Encode(w io.Writer, v interface{}, opts JSONOptions) error
+}
+
+// JSONOptions are the output options passed to a JSONEngine.
+type JSONOptions struct {
+	Indent     string // Indentation per level; empty for compact output
+	EscapeHTML bool   // Escape <, > and & inside strings
+}
+
+// StdJSONEngine is the JSONEngine backed by encoding/json.
+type StdJSONEngine struct{}
+
+// Encode writes v followed by a newline, like json.Encoder.
+func (StdJSONEngine) Encode(w io.Writer, v interface{}, opts JSONOptions) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(opts.EscapeHTML)
+	if opts.Indent != "" {
+		enc.SetIndent("", opts.Indent)
+	}
+	return enc.Encode(v)
+}
+
+// JSONConfig represents configuration options for JSON responses.
+type JSONConfig struct {
+	Engine            JSONEngine // Encoder implementation; defaults to StdJSONEngine
+	Indent            string     // Indentation per level; empty for compact output
+	DisableHTMLEscape bool       // Write <, > and & inside strings unescaped
+}
+
+// defaultJSONConfig applies outside a Router and to Routers without a JSON
+// configuration.
+var defaultJSONConfig = &JSONConfig{Engine: StdJSONEngine{}}
+
+// SetJSONConfig sets the configuration used by the router's JSON responses:
+// WriteJSON, Context.JSON, error responses, streams, events, WebSocket
+// messages and the JSON encoder of Render.
+func (r *Router) SetJSONConfig(config JSONConfig) {
+	if config.Engine == nil {
+		config.Engine = StdJSONEngine{}
+	}
+	r.jsonConfig = &config
+}
+
+// jsonConfigFrom returns the JSON configuration of the Router serving ctx.
+func jsonConfigFrom(ctx context.Context) *JSONConfig {
+	if r := routerFromContext(ctx); r != nil && r.jsonConfig != nil {
+		return r.jsonConfig
+	}
+	return defaultJSONConfig
+}
+
+// encode encodes v with the configured engine and options.
+func (c *JSONConfig) encode(w io.Writer, v interface{}) error {
+	return c.Engine.Encode(w, v, JSONOptions{Indent: c.Indent, EscapeHTML: !c.DisableHTMLEscape})
+}
+
+// encodeCompact encodes v with the configured engine on a single line.
+func (c *JSONConfig) encodeCompact(w io.Writer, v interface{}) error {
+	return c.Engine.Encode(w, v, JSONOptions{EscapeHTML: !c.DisableHTMLEscape})
+}
+
+// maxPooledBufferSize keeps unusually large buffers from being retained by the pool.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buf)
+	}
+}
+
+// internalServerErrorJSON is sent when a JSON response cannot be encoded.
+var internalServerErrorJSON = []byte(`{"error":"Internal Server Error"}` + "\n")
+
+// JSONResponse sends a JSON response with the given data and status code,
+// using the default JSON configuration; WriteJSON uses the Router's.
+// The data is encoded into a buffer before anything is written, so an encoding
+// failure results in a complete 500 JSON error instead of a partial body.
 func JSONResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	writeJSON(context.Background(), w, defaultJSONConfig, data, statusCode)
+}
+
+// WriteJSON is JSONResponse using the JSON configuration of the Router
+// serving the request.
+func WriteJSON(w http.ResponseWriter, req *http.Request, data interface{}, statusCode int) {
+	writeJSON(req.Context(), w, jsonConfigFrom(req.Context()), data, statusCode)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, config *JSONConfig, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode) // Set the status code once
-	if data != nil {
-		if err := json.NewEncoder(w).Encode(data); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+	if data == nil {
+		w.WriteHeader(statusCode)
+		return
 	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := config.encode(buf, data); err != nil {
+		LoggerFrom(ctx).Log(ctx, slog.LevelError, "Failed to encode JSON response", "error", err)
+		writeBody(w, internalServerErrorJSON, http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, buf.Bytes(), statusCode)
+}
+
+// writeBody sends a complete body with its Content-Length.
+func writeBody(w http.ResponseWriter, body []byte, statusCode int) {
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(statusCode)
+	w.Write(body)
 }
 
 // JSONError writes an error message in JSON format to the client.
@@ -23,16 +145,19 @@ func JSONError(w http.ResponseWriter, message string, statusCode int) {
 }
 
 // ParseJSONBody parses the JSON body of a request into the provided struct.
+// Any JSON media type, including ones with parameters such as charset or a
+// "+json" suffix, is accepted. On failure an error response is written and
+// the *BodyError is returned.
 func ParseJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
-	if r.Header.Get("Content-Type") != "application/json" {
-		JSONError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
-		return fmt.Errorf("content type must be application/json")
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		WriteJSON(w, r, map[string]string{"error": "Content-Type must be application/json"}, http.StatusUnsupportedMediaType)
+		return &BodyError{Kind: BodyUnsupportedMediaType, Err: fmt.Errorf("content type must be application/json")}
 	}
 
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
-	if err := dec.Decode(dst); err != nil {
-		JSONError(w, "Invalid JSON body", http.StatusBadRequest)
+	if err := decodeWith(r, JSONDecoder, dst); err != nil {
+		httpErr := toHTTPError(err)
+		WriteJSON(w, r, map[string]string{"error": httpErr.Message}, httpErr.Status)
 		return err
 	}
 	return nil
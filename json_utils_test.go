@@ -0,0 +1,80 @@
+package gorouter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestJSONResponseEncodeFailure(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	JSONResponse(recorder, map[string]interface{}{"ch": make(chan int)}, http.StatusOK)
+
+	checkResponse(t, recorder, http.StatusInternalServerError, `{"error":"Internal Server Error"}`+"\n", "Content-Type", "application/json")
+	if cl := recorder.Header().Get("Content-Length"); cl != strconv.Itoa(recorder.Body.Len()) {
+		t.Errorf("Expected Content-Length %d, got %s", recorder.Body.Len(), cl)
+	}
+}
+
+func TestJSONResponseContentLength(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	JSONResponse(recorder, map[string]string{"hello": "world"}, http.StatusCreated)
+
+	checkResponse(t, recorder, http.StatusCreated, `{"hello":"world"}`+"\n", "Content-Length", "18")
+}
+
+func TestRouterJSONConfig(t *testing.T) {
+	data := map[string]string{"html": "<b>"}
+	handler := func(c *Context) {
+		c.JSON(data, http.StatusOK)
+	}
+	pretty := NewRouter()
+	pretty.SetJSONConfig(JSONConfig{Indent: "  ", DisableHTMLEscape: true})
+	pretty.AddRoute(http.MethodGet, "/", Adapt(handler))
+	plain := NewRouter()
+	plain.AddRoute(http.MethodGet, "/", Adapt(handler))
+
+	recorder := httptest.NewRecorder()
+	pretty.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	checkResponse(t, recorder, http.StatusOK, "{\n  \"html\": \"<b>\"\n}\n", "", "")
+
+	recorder = httptest.NewRecorder()
+	plain.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	checkResponse(t, recorder, http.StatusOK, `{"html":"\u003cb\u003e"}`+"\n", "", "")
+
+	recorder = httptest.NewRecorder()
+	JSONResponse(recorder, data, http.StatusOK)
+	checkResponse(t, recorder, http.StatusOK, `{"html":"\u003cb\u003e"}`+"\n", "", "")
+}
+
+// stubEngine is a JSONEngine stub that ignores its input.
+type stubEngine struct{}
+
+func (stubEngine) Encode(w io.Writer, v interface{}, opts JSONOptions) error {
+	_, err := io.WriteString(w, `"CUSTOM"`)
+	return err
+}
+
+func TestCustomJSONEngine(t *testing.T) {
+	r := NewRouter()
+	r.SetJSONConfig(JSONConfig{Engine: stubEngine{}})
+	r.AddRoute(http.MethodGet, "/render", func(w http.ResponseWriter, req *http.Request) {
+		Render(w, req, "anything", http.StatusOK)
+	})
+	r.AddRoute(http.MethodGet, "/json", func(w http.ResponseWriter, req *http.Request) {
+		WriteJSON(w, req, "anything", http.StatusOK)
+	})
+
+	for _, path := range []string{"/render", "/json"} {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		checkResponse(t, recorder, http.StatusOK, `"CUSTOM"`, "Content-Type", "application/json")
+	}
+
+	// Outside the router, the default engine applies.
+	recorder := httptest.NewRecorder()
+	Render(recorder, httptest.NewRequest(http.MethodGet, "/", nil), "anything", http.StatusOK)
+	checkResponse(t, recorder, http.StatusOK, `"anything"`+"\n", "", "")
+}
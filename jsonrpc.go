@@ -0,0 +1,349 @@
+package gorouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/saadi925/gorouter/validation"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+	RPCServerError    = -32000 // Used for HTTPErrors with a 4xx status returned by methods
+)
+
+// RPCError is a JSON-RPC error object. Methods return it to choose the code
+// sent to the client.
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NewRPCError creates an RPCError.
+func NewRPCError(code int, message string, data interface{}) *RPCError {
+	return &RPCError{Code: code, Message: message, Data: data}
+}
+
+// Error implements the error interface.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("jsonrpc: %d %s", e.Code, e.Message)
+}
+
+// rpcMethod calls a registered method with raw params.
+type rpcMethod func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// rpcRequest is a single JSON-RPC request. A nil ID marks a notification.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcResponse is a single JSON-RPC response.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcRequestKey stores the HTTP request in a method's context.
+type rpcRequestKey struct{}
+
+// RPCServer is an http.Handler serving JSON-RPC 2.0 over HTTP POST. Mount it
+// on any route:
+//
+//	rpc := gorouter.NewRPCServer()
+//	gorouter.RegisterMethod(rpc, "sum", sum)
+//	router.AddRoute(http.MethodPost, "/rpc", rpc.ServeHTTP)
+type RPCServer struct {
+	methods            map[string]rpcMethod
+	dependencyRegistry *DependencyRegistry
+}
+
+// NewRPCServer creates an RPCServer without methods.
+func NewRPCServer() *RPCServer {
+	return &RPCServer{methods: make(map[string]rpcMethod)}
+}
+
+// Provide registers a dependency available to methods through Resolve, in
+// addition to those provided on the router or route.
+func (s *RPCServer) Provide(key string, dependency interface{}) {
+	if s.dependencyRegistry == nil {
+		s.dependencyRegistry = NewDependencyRegistry()
+	}
+	s.dependencyRegistry.Provide(key, dependency)
+}
+
+// Methods returns the names of the registered methods, sorted.
+func (s *RPCServer) Methods() []string {
+	names := make([]string, 0, len(s.methods))
+	for name := range s.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterMethod registers fn as the method name of s. Params are decoded
+// into P: objects by field name, arrays by position into slices or, in field
+// order, into structs. Struct params are validated with the validation
+// package. Errors returned by fn are sent as follows: *RPCError as is,
+// validation errors as RPCInvalidParams, HTTPErrors with a 4xx status as
+// RPCServerError, and anything else as RPCInternalError, which is logged.
+// Dependencies are resolved from ctx with Resolve; RPCHTTPRequest returns the
+// HTTP request.
+func RegisterMethod[P, R any](s *RPCServer, name string, fn func(ctx context.Context, params P) (R, error)) {
+	if strings.HasPrefix(name, "rpc.") {
+		panic("gorouter: JSON-RPC method names starting with rpc. are reserved")
+	}
+	// Build the validator once per method; it caches struct metadata.
+	var validator *validation.Validator
+	if t := reflect.TypeFor[P](); t.Kind() == reflect.Struct || t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		validator = validation.NewValidator()
+	}
+	s.methods[name] = func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var params P
+		if err := decodeRPCParams(raw, &params); err != nil {
+			return nil, NewRPCError(RPCInvalidParams, "Invalid params", err.Error())
+		}
+		if validator != nil && reflect.Indirect(reflect.ValueOf(params)).Kind() == reflect.Struct {
+			if err := validator.ValidateStruct(params); err != nil {
+				return nil, err
+			}
+		}
+		return fn(ctx, params)
+	}
+}
+
+// RPCHTTPRequest returns the HTTP request carrying the JSON-RPC call, or nil
+// outside of a method.
+func RPCHTTPRequest(ctx context.Context) *http.Request {
+	req, _ := ctx.Value(rpcRequestKey{}).(*http.Request)
+	return req
+}
+
+// ServeHTTP implements the http.Handler interface for RPCServer.
+func (s *RPCServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if s.dependencyRegistry != nil {
+		s.dependencyRegistry.Middleware(http.HandlerFunc(s.serve)).ServeHTTP(w, req)
+		return
+	}
+	s.serve(w, req)
+}
+
+// serve handles a single request or a batch.
+func (s *RPCServer) serve(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		RenderError(w, req, NewHTTPError(http.StatusMethodNotAllowed, ""))
+		return
+	}
+
+	body := req.Body
+	if limit := maxBodySize(req); limit > 0 {
+		body = http.MaxBytesReader(nil, body, limit)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		RenderError(w, req, bodyError(err, BodyInvalid))
+		return
+	}
+	data = bytes.TrimSpace(data)
+
+	var responses interface{}
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		switch {
+		case json.Unmarshal(data, &batch) != nil:
+			responses = rpcErrorResponse(nil, NewRPCError(RPCParseError, "Parse error", nil))
+		case len(batch) == 0:
+			responses = rpcErrorResponse(nil, NewRPCError(RPCInvalidRequest, "Invalid Request", nil))
+		default:
+			var results []*rpcResponse
+			for _, raw := range batch {
+				if resp := s.call(req, raw); resp != nil {
+					results = append(results, resp)
+				}
+			}
+			if results != nil {
+				responses = results
+			}
+		}
+	} else if resp := s.call(req, data); resp != nil {
+		responses = resp
+	}
+
+	// Notifications get no response body.
+	if responses == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(responses); err != nil {
+		RenderError(w, req, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeBody(w, buf.Bytes(), http.StatusOK)
+}
+
+// call runs a single request and returns its response, or nil for notifications.
+func (s *RPCServer) call(req *http.Request, raw json.RawMessage) *rpcResponse {
+	if !json.Valid(raw) {
+		return rpcErrorResponse(nil, NewRPCError(RPCParseError, "Parse error", nil))
+	}
+	var rpcReq rpcRequest
+	if err := json.Unmarshal(raw, &rpcReq); err != nil || !validRPCRequest(rpcReq) {
+		id := rpcReq.ID
+		if !validRPCID(id) {
+			id = nil
+		}
+		return rpcErrorResponse(id, NewRPCError(RPCInvalidRequest, "Invalid Request", nil))
+	}
+
+	method, ok := s.methods[rpcReq.Method]
+	if !ok {
+		if rpcReq.ID == nil {
+			return nil
+		}
+		return rpcErrorResponse(rpcReq.ID, NewRPCError(RPCMethodNotFound, "Method not found", nil))
+	}
+
+	result, err := s.invoke(req, method, rpcReq)
+	if err != nil {
+		rpcErr := rpcErrorFrom(req, err)
+		if rpcReq.ID == nil {
+			return nil
+		}
+		return rpcErrorResponse(rpcReq.ID, rpcErr)
+	}
+	if rpcReq.ID == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return rpcErrorResponse(rpcReq.ID, rpcErrorFrom(req, err))
+	}
+	return &rpcResponse{JSONRPC: "2.0", Result: encoded, ID: rpcReq.ID}
+}
+
+// invoke calls a method, converting panics into errors so one call cannot
+// fail a whole batch.
+func (s *RPCServer) invoke(req *http.Request, method rpcMethod, rpcReq rpcRequest) (result interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: string(debug.Stack())}
+		}
+	}()
+	ctx := context.WithValue(req.Context(), rpcRequestKey{}, req)
+	return method(ctx, rpcReq.Params)
+}
+
+// validRPCRequest checks the members of a decoded request.
+func validRPCRequest(rpcReq rpcRequest) bool {
+	if rpcReq.JSONRPC != "2.0" || rpcReq.Method == "" || !validRPCID(rpcReq.ID) {
+		return false
+	}
+	params := bytes.TrimSpace(rpcReq.Params)
+	return len(params) == 0 || params[0] == '{' || params[0] == '['
+}
+
+// validRPCID reports whether id is absent, null, a string or a number.
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch id[0] {
+	case '{', '[', 't', 'f':
+		return false
+	}
+	return true
+}
+
+// rpcErrorResponse builds an error response; a nil id is sent as null.
+func rpcErrorResponse(id json.RawMessage, rpcErr *RPCError) *rpcResponse {
+	return &rpcResponse{JSONRPC: "2.0", Error: rpcErr, ID: id}
+}
+
+// rpcErrorFrom converts an error returned by a method into an RPCError.
+func rpcErrorFrom(req *http.Request, err error) *RPCError {
+	var rpcErr *RPCError
+	var validationErrors validation.Errors
+	var httpErr *HTTPError
+	switch {
+	case errors.As(err, &rpcErr):
+		return rpcErr
+	case errors.As(err, &validationErrors):
+		return NewRPCError(RPCInvalidParams, "Invalid params", invalidParams(validationErrors))
+	case errors.As(err, &httpErr) && httpErr.Status < http.StatusInternalServerError:
+		return NewRPCError(RPCServerError, httpErr.Message, httpErr.Details)
+	default:
+		logRequestError(req, err, slog.LevelError)
+		return NewRPCError(RPCInternalError, "Internal error", nil)
+	}
+}
+
+// decodeRPCParams decodes named or positional params into dst.
+func decodeRPCParams(raw json.RawMessage, dst interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	target := reflect.ValueOf(dst).Elem()
+	for target.Kind() == reflect.Pointer {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+	if raw[0] != '[' || target.Kind() != reflect.Struct {
+		return strictUnmarshal(raw, dst)
+	}
+
+	var positional []json.RawMessage
+	if err := json.Unmarshal(raw, &positional); err != nil {
+		return err
+	}
+	var fields []int
+	for i := 0; i < target.NumField(); i++ {
+		if field := target.Type().Field(i); field.IsExported() && field.Tag.Get("json") != "-" {
+			fields = append(fields, i)
+		}
+	}
+	if len(positional) > len(fields) {
+		return fmt.Errorf("expected at most %d params, got %d", len(fields), len(positional))
+	}
+	for i, value := range positional {
+		if err := strictUnmarshal(value, target.Field(fields[i]).Addr().Interface()); err != nil {
+			return fmt.Errorf("param %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// strictUnmarshal decodes JSON rejecting unknown object fields.
+func strictUnmarshal(data []byte, dst interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
@@ -0,0 +1,150 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type sumParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type greetParams struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func newTestRPCServer() *RPCServer {
+	rpc := NewRPCServer()
+	RegisterMethod(rpc, "sum", func(ctx context.Context, p sumParams) (int, error) {
+		return p.A + p.B, nil
+	})
+	RegisterMethod(rpc, "greet", func(ctx context.Context, p greetParams) (string, error) {
+		greeting, err := Resolve[string](ctx, "greeting")
+		if err != nil {
+			return "", err
+		}
+		return greeting + ", " + p.Name, nil
+	})
+	RegisterMethod(rpc, "fail", func(ctx context.Context, p []string) (interface{}, error) {
+		if len(p) > 0 && p[0] == "custom" {
+			return nil, NewRPCError(42, "Custom failure", map[string]int{"retry": 3})
+		}
+		if len(p) > 0 && p[0] == "panic" {
+			panic("boom")
+		}
+		return nil, errors.New("database is down")
+	})
+	rpc.Provide("greeting", "Hello")
+	return rpc
+}
+
+func rpcCall(t *testing.T, handler http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRPCSingleCalls(t *testing.T) {
+	router := NewRouter()
+	router.AddRoute(http.MethodPost, "/rpc", newTestRPCServer().ServeHTTP)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"named params", `{"jsonrpc":"2.0","method":"sum","params":{"a":1,"b":2},"id":1}`,
+			`{"jsonrpc":"2.0","result":3,"id":1}`},
+		{"positional params", `{"jsonrpc":"2.0","method":"sum","params":[4,5],"id":"x"}`,
+			`{"jsonrpc":"2.0","result":9,"id":"x"}`},
+		{"dependency", `{"jsonrpc":"2.0","method":"greet","params":{"name":"Ada"},"id":2}`,
+			`{"jsonrpc":"2.0","result":"Hello, Ada","id":2}`},
+		{"parse error", `{"jsonrpc":"2.0",`,
+			`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{"invalid request", `{"jsonrpc":"1.0","method":"sum","id":3}`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":3}`},
+		{"method not found", `{"jsonrpc":"2.0","method":"nope","id":4}`,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":4}`},
+		{"invalid params", `{"jsonrpc":"2.0","method":"sum","params":{"c":1},"id":5}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"json: unknown field \"c\""},"id":5}`},
+		{"validation", `{"jsonrpc":"2.0","method":"greet","params":{},"id":6}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":[{"name":"Name","reason":"Name is a required field"}]},"id":6}`},
+		{"custom error", `{"jsonrpc":"2.0","method":"fail","params":["custom"],"id":7}`,
+			`{"jsonrpc":"2.0","error":{"code":42,"message":"Custom failure","data":{"retry":3}},"id":7}`},
+		{"internal error", `{"jsonrpc":"2.0","method":"fail","id":8}`,
+			`{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":8}`},
+		{"panic", `{"jsonrpc":"2.0","method":"fail","params":["panic"],"id":9}`,
+			`{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":9}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := rpcCall(t, router, tt.body)
+			checkResponse(t, recorder, http.StatusOK, tt.want+"\n", "Content-Type", "application/json")
+		})
+	}
+}
+
+func TestRPCBatch(t *testing.T) {
+	rpc := newTestRPCServer()
+
+	recorder := rpcCall(t, rpc, `[
+		{"jsonrpc":"2.0","method":"sum","params":[1,1],"id":1},
+		{"jsonrpc":"2.0","method":"sum","params":[2,2]},
+		1,
+		{"jsonrpc":"2.0","method":"nope","id":2}
+	]`)
+	want := `[{"jsonrpc":"2.0","result":2,"id":1},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},` +
+		`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":2}]` + "\n"
+	checkResponse(t, recorder, http.StatusOK, want, "", "")
+
+	recorder = rpcCall(t, rpc, `[]`)
+	checkResponse(t, recorder, http.StatusOK, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`+"\n", "", "")
+}
+
+func TestRPCNotifications(t *testing.T) {
+	rpc := newTestRPCServer()
+
+	recorder := rpcCall(t, rpc, `{"jsonrpc":"2.0","method":"sum","params":[1,2]}`)
+	checkResponse(t, recorder, http.StatusNoContent, "", "", "")
+
+	recorder = rpcCall(t, rpc, `[{"jsonrpc":"2.0","method":"sum"},{"jsonrpc":"2.0","method":"fail"}]`)
+	checkResponse(t, recorder, http.StatusNoContent, "", "", "")
+}
+
+func TestRPCMethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/rpc", nil)
+	recorder := httptest.NewRecorder()
+	newTestRPCServer().ServeHTTP(recorder, req)
+
+	checkResponse(t, recorder, http.StatusMethodNotAllowed, `{"error":"Method Not Allowed"}`+"\n", "Allow", http.MethodPost)
+}
+
+func TestRPCRouteDependencies(t *testing.T) {
+	rpc := NewRPCServer()
+	RegisterMethod(rpc, "version", func(ctx context.Context, _ struct{}) (string, error) {
+		if RPCHTTPRequest(ctx) == nil {
+			return "", errors.New("missing request")
+		}
+		return Resolve[string](ctx, "version")
+	})
+
+	router := NewRouter()
+	api := router.Group("/api")
+	api.Provide("version", "v1")
+	api.POST("/rpc", rpc.ServeHTTP)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"version","id":1}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	checkResponse(t, recorder, http.StatusOK, `{"jsonrpc":"2.0","result":"v1","id":1}`+"\n", "", "")
+}
@@ -0,0 +1,196 @@
+package gorouter
+
+import (
+	"container/list"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Defaults used when KeyedRateLimiterConfig leaves a field unset.
+const (
+	DefaultRateLimitMaxKeys = 10000
+	DefaultRateLimitIdleTTL = 10 * time.Minute
+)
+
+// KeyFunc returns the key a request is rate limited by. Requests with an
+// empty key are not limited.
+type KeyFunc func(req *http.Request) string
+
+// KeyByIP keys requests by the client IP of the connection. Proxy headers are
+// not trusted; use KeyByHeader behind a proxy that sets one.
+func KeyByIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// KeyByHeader keys requests by the value of a header, such as an API key.
+func KeyByHeader(name string) KeyFunc {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// KeyByUser keys requests by the user set with WithUser. Anonymous requests
+// get an empty key; combine with FirstKey to limit them too.
+func KeyByUser(req *http.Request) string {
+	return UserFromContext(req.Context())
+}
+
+// KeyByRoute keys requests by their route pattern, so every client shares the
+// route's budget. The route pattern is available in all middleware, including
+// Router middleware; requests matching no route get an empty key, so they are
+// not limited.
+func KeyByRoute(req *http.Request) string {
+	return RoutePattern(req)
+}
+
+// CombineKeys joins the keys of several functions, e.g. route and IP for
+// per-client limits on each route. The key is empty if any part is.
+func CombineKeys(keys ...KeyFunc) KeyFunc {
+	return func(req *http.Request) string {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			if parts[i] = key(req); parts[i] == "" {
+				return ""
+			}
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// FirstKey returns the first non-empty key, e.g. the user and then the IP.
+func FirstKey(keys ...KeyFunc) KeyFunc {
+	return func(req *http.Request) string {
+		for _, key := range keys {
+			if k := key(req); k != "" {
+				return k
+			}
+		}
+		return ""
+	}
+}
+
+// KeyedRateLimiterConfig represents configuration options for a KeyedRateLimiter.
+type KeyedRateLimiterConfig struct {
+	Rate           rate.Limit             // Requests per second allowed for each key
+	Burst          int                    // Bucket size for each key
+	Key            KeyFunc                // Defaults to KeyByIP
+	MaxKeys        int                    // Buckets kept before the least recently used is evicted; defaults to DefaultRateLimitMaxKeys
+	IdleTTL        time.Duration          // Buckets unused this long are evicted; defaults to DefaultRateLimitIdleTTL
+	OnReject       RateLimitRejectHandler // Defaults to DefaultRateLimitRejectHandler
+	Wait           bool                   // Delay requests over the limit instead of rejecting them
+	MaxWait        time.Duration          // Longest delay in wait mode; zero waits as long as the request context allows
+	DisableHeaders bool                   // Omit the RateLimit-* and Retry-After headers
+}
+
+// KeyedRateLimiter keeps a token bucket per key, so one noisy client does not
+// throttle the others. Buckets live in a bounded LRU.
+type KeyedRateLimiter struct {
+	config  KeyedRateLimiterConfig
+	policy  rateLimitPolicy
+	now     func() time.Time
+	mu      sync.Mutex
+	lru     *list.List // of *keyedBucket, most recently used first
+	buckets map[string]*list.Element
+}
+
+// keyedBucket is the token bucket of one key.
+type keyedBucket struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewKeyedRateLimiter creates a KeyedRateLimiter.
+func NewKeyedRateLimiter(config KeyedRateLimiterConfig) *KeyedRateLimiter {
+	if config.Key == nil {
+		config.Key = KeyByIP
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = DefaultRateLimitMaxKeys
+	}
+	if config.IdleTTL <= 0 {
+		config.IdleTTL = DefaultRateLimitIdleTTL
+	}
+	return &KeyedRateLimiter{
+		config:  config,
+		policy:  newRateLimitPolicy(config.OnReject, config.Wait, config.MaxWait, config.DisableHeaders),
+		now:     time.Now,
+		lru:     list.New(),
+		buckets: make(map[string]*list.Element),
+	}
+}
+
+// Allow reports whether a request for key may proceed now.
+func (l *KeyedRateLimiter) Allow(key string) bool {
+	now := l.now()
+	return l.bucket(key, now).AllowN(now, 1)
+}
+
+// Len returns the number of buckets currently kept.
+func (l *KeyedRateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
+
+// Limit is a middleware applying the limit of each request's key.
+func (l *KeyedRateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := l.config.Key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := l.now()
+		l.policy.serve(w, r, l.bucket(key, now), now, next)
+	})
+}
+
+// bucket returns the limiter of key, creating it and evicting idle or excess
+// buckets as needed.
+func (l *KeyedRateLimiter) bucket(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for back := l.lru.Back(); back != nil; back = l.lru.Back() {
+		b := back.Value.(*keyedBucket)
+		if now.Sub(b.lastSeen) < l.config.IdleTTL {
+			break
+		}
+		l.lru.Remove(back)
+		delete(l.buckets, b.key)
+	}
+
+	if elem, ok := l.buckets[key]; ok {
+		b := elem.Value.(*keyedBucket)
+		b.lastSeen = now
+		l.lru.MoveToFront(elem)
+		return b.limiter
+	}
+
+	if l.lru.Len() >= l.config.MaxKeys {
+		back := l.lru.Back()
+		l.lru.Remove(back)
+		delete(l.buckets, back.Value.(*keyedBucket).key)
+	}
+	b := &keyedBucket{key: key, limiter: rate.NewLimiter(l.config.Rate, l.config.Burst), lastSeen: now}
+	l.buckets[key] = l.lru.PushFront(b)
+	return b.limiter
+}
+
+// RateLimit limits the routes added to the group afterwards with a new
+// KeyedRateLimiter, which is returned so it can be shared with other routes.
+func (rg *RouteGroup) RateLimit(config KeyedRateLimiterConfig) *KeyedRateLimiter {
+	limiter := NewKeyedRateLimiter(config)
+	rg.Use(limiter.Limit)
+	return limiter
+}
@@ -0,0 +1,146 @@
+package gorouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func requestFrom(remoteAddr, path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestKeyedRateLimiterPerClient(t *testing.T) {
+	limiter := NewKeyedRateLimiter(KeyedRateLimiterConfig{Rate: 1, Burst: 2})
+	handler := limiter.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := func(remoteAddr string, n int) []int {
+		var got []int
+		for i := 0; i < n; i++ {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, requestFrom(remoteAddr, "/"))
+			got = append(got, recorder.Code)
+		}
+		return got
+	}
+
+	if got := codes("10.0.0.1:1234", 3); got[0] != 200 || got[1] != 200 || got[2] != 429 {
+		t.Errorf("Expected the noisy client to be limited on its third request, got %v", got)
+	}
+	if got := codes("10.0.0.2:1234", 1); got[0] != 200 {
+		t.Errorf("Expected another client to be unaffected, got %v", got)
+	}
+	if got := codes("10.0.0.1:5678", 1); got[0] != 429 {
+		t.Errorf("Expected the key to ignore the client port, got %v", got)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, requestFrom("10.0.0.1:1234", "/"))
+	if recorder.Header().Get("Retry-After") != "1" || recorder.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("Expected rate limit headers from the client's bucket, got %v", recorder.Header())
+	}
+}
+
+func TestKeyedRateLimiterRouterUse(t *testing.T) {
+	limiter := NewKeyedRateLimiter(KeyedRateLimiterConfig{Rate: 0.001, Burst: 2})
+	router := NewRouter()
+	router.Use(limiter.Limit)
+	router.AddRoute(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, requestFrom("1.2.3.4:1000", "/"))
+	checkResponse(t, recorder, http.StatusOK, "ok", "RateLimit-Remaining", "1")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, requestFrom("1.2.3.4:1000", "/"))
+	checkResponse(t, recorder, http.StatusOK, "ok", "RateLimit-Remaining", "0")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, requestFrom("1.2.3.4:1000", "/"))
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the third request to be limited, got %d", recorder.Code)
+	}
+}
+
+func TestKeyedRateLimiterEviction(t *testing.T) {
+	now := time.Now()
+	limiter := NewKeyedRateLimiter(KeyedRateLimiterConfig{Rate: 1, Burst: 1, MaxKeys: 2, IdleTTL: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("a")
+	limiter.Allow("b")
+	limiter.Allow("a")
+	limiter.Allow("c") // evicts b, the least recently used
+	if limiter.Len() != 2 {
+		t.Errorf("Expected 2 buckets, got %d", limiter.Len())
+	}
+	if limiter.Allow("a") {
+		t.Error("Expected a to keep its exhausted bucket")
+	}
+	if !limiter.Allow("b") {
+		t.Error("Expected b to get a fresh bucket after eviction")
+	}
+
+	now = now.Add(2 * time.Minute)
+	limiter.Allow("d")
+	if limiter.Len() != 1 {
+		t.Errorf("Expected idle buckets to be evicted, got %d buckets", limiter.Len())
+	}
+}
+
+func TestRateLimitKeyFuncs(t *testing.T) {
+	req := WithUser(requestFrom("192.0.2.1:80", "/"), "alice")
+	req.Header.Set("X-API-Key", "k1")
+
+	tests := []struct {
+		name string
+		key  KeyFunc
+		want string
+	}{
+		{"ip", KeyByIP, "192.0.2.1"},
+		{"header", KeyByHeader("X-API-Key"), "k1"},
+		{"user", KeyByUser, "alice"},
+		{"combined", CombineKeys(KeyByUser, KeyByIP), "alice|192.0.2.1"},
+		{"combined missing part", CombineKeys(KeyByHeader("X-Missing"), KeyByIP), ""},
+		{"first", FirstKey(KeyByHeader("X-Missing"), KeyByIP), "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		if got := tt.key(req); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRouteGroupRateLimit(t *testing.T) {
+	router := NewRouter()
+	api := router.Group("/api")
+	api.RateLimit(KeyedRateLimiterConfig{Rate: 1, Burst: 1})
+	api.GET("/a", func(w http.ResponseWriter, r *http.Request) {})
+
+	perRoute := NewKeyedRateLimiter(KeyedRateLimiterConfig{Rate: 1, Burst: 2, Key: KeyByRoute})
+	router.AddRoute(http.MethodGet, "/items/:id", func(w http.ResponseWriter, r *http.Request) {}, perRoute.Limit)
+
+	serve := func(remoteAddr, path string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, requestFrom(remoteAddr, path))
+		return recorder.Code
+	}
+
+	if serve("10.0.0.1:1", "/api/a") != 200 || serve("10.0.0.1:1", "/api/a") != 429 {
+		t.Error("Expected the group limit to apply per client")
+	}
+	if serve("10.0.0.2:1", "/api/a") != 200 {
+		t.Error("Expected the group limit to be separate for each client")
+	}
+
+	// Every client shares the route pattern's budget.
+	if serve("10.0.0.1:1", "/items/1") != 200 || serve("10.0.0.2:1", "/items/2") != 200 || serve("10.0.0.3:1", "/items/3") != 429 {
+		t.Error("Expected the route limit to be shared across clients and paths")
+	}
+	if perRoute.Len() != 1 {
+		t.Errorf("Expected one bucket for the route pattern, got %d", perRoute.Len())
+	}
+}
@@ -0,0 +1,318 @@
+package gorouter
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Limiter decides whether requests for a key are within a rate limit.
+type Limiter interface {
+	// AllowN reports whether n requests for key may proceed now and records
+	// them if so. The returned info describes the key's state afterwards.
+	AllowN(ctx context.Context, key string, n int) (info RateLimitInfo, allowed bool, err error)
+}
+
+// LimiterConfig represents configuration options for the Store-based limiters.
+type LimiterConfig struct {
+	Limit  int           // Requests allowed per Window
+	Window time.Duration // Length of the window
+	Store  Store         // Defaults to a new MemoryStore; share a backend Store across replicas
+	Prefix string        // Prepended to keys so several limiters can share a Store
+}
+
+// storeLimiter holds what the Store-based limiters have in common.
+type storeLimiter struct {
+	config LimiterConfig
+	now    func() time.Time
+}
+
+func newStoreLimiter(config LimiterConfig) storeLimiter {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Limit <= 0 {
+		config.Limit = 1
+	}
+	if config.Window <= 0 {
+		config.Window = time.Second
+	}
+	return storeLimiter{config: config, now: time.Now}
+}
+
+// updateState decodes the JSON state of key into a new S, lets fn change it and
+// stores it again.
+func updateState[S any](ctx context.Context, l storeLimiter, key string, ttl time.Duration, fn func(state *S)) error {
+	return l.config.Store.Update(ctx, l.config.Prefix+key, ttl, func(data []byte) ([]byte, error) {
+		var state S
+		if data != nil {
+			if err := json.Unmarshal(data, &state); err != nil {
+				return nil, err
+			}
+		}
+		fn(&state)
+		return json.Marshal(state)
+	})
+}
+
+// FixedWindowLimiter allows Limit requests in each aligned window. It is
+// cheap but allows up to twice the limit around window boundaries.
+type FixedWindowLimiter struct {
+	storeLimiter
+}
+
+// NewFixedWindowLimiter creates a FixedWindowLimiter.
+func NewFixedWindowLimiter(config LimiterConfig) *FixedWindowLimiter {
+	return &FixedWindowLimiter{newStoreLimiter(config)}
+}
+
+type fixedWindowState struct {
+	Start int64 `json:"s"`
+	Count int   `json:"c"`
+}
+
+// AllowN implements Limiter.
+func (l *FixedWindowLimiter) AllowN(ctx context.Context, key string, n int) (info RateLimitInfo, allowed bool, err error) {
+	now := l.now()
+	window := l.config.Window
+	start := now.Truncate(window)
+	err = updateState(ctx, l.storeLimiter, key, window, func(state *fixedWindowState) {
+		if state.Start != start.UnixNano() {
+			*state = fixedWindowState{Start: start.UnixNano()}
+		}
+		allowed = state.Count+n <= l.config.Limit
+		if allowed {
+			state.Count += n
+		}
+		info = RateLimitInfo{
+			Limit:     l.config.Limit,
+			Remaining: l.config.Limit - state.Count,
+			Reset:     start.Add(window).Sub(now),
+		}
+		if !allowed && n <= l.config.Limit {
+			info.RetryAfter = info.Reset
+		}
+	})
+	return info, allowed, err
+}
+
+// SlidingLogLimiter allows Limit requests in any Window-long period by
+// keeping the time of each request. It is exact but stores up to Limit
+// timestamps per key.
+type SlidingLogLimiter struct {
+	storeLimiter
+}
+
+// NewSlidingLogLimiter creates a SlidingLogLimiter.
+func NewSlidingLogLimiter(config LimiterConfig) *SlidingLogLimiter {
+	return &SlidingLogLimiter{newStoreLimiter(config)}
+}
+
+// AllowN implements Limiter.
+func (l *SlidingLogLimiter) AllowN(ctx context.Context, key string, n int) (info RateLimitInfo, allowed bool, err error) {
+	now := l.now()
+	window := l.config.Window
+	err = updateState(ctx, l.storeLimiter, key, window, func(log *[]int64) {
+		cutoff := now.Add(-window).UnixNano()
+		kept := (*log)[:0]
+		for _, t := range *log {
+			if t > cutoff {
+				kept = append(kept, t)
+			}
+		}
+
+		allowed = len(kept)+n <= l.config.Limit
+		if allowed {
+			for i := 0; i < n; i++ {
+				kept = append(kept, now.UnixNano())
+			}
+		}
+		*log = kept
+
+		info = RateLimitInfo{Limit: l.config.Limit, Remaining: l.config.Limit - len(kept)}
+		if len(kept) > 0 {
+			info.Reset = time.Unix(0, kept[len(kept)-1]).Add(window).Sub(now)
+		}
+		// Enough of the oldest requests must expire to make room for n.
+		if i := len(kept) + n - l.config.Limit - 1; !allowed && n <= l.config.Limit && i < len(kept) {
+			info.RetryAfter = time.Unix(0, kept[i]).Add(window).Sub(now)
+		}
+	})
+	return info, allowed, err
+}
+
+// SlidingWindowLimiter approximates a sliding window by weighting the count of
+// the previous window by how much of it still overlaps the sliding window. It
+// stores two counters per key.
+type SlidingWindowLimiter struct {
+	storeLimiter
+}
+
+// NewSlidingWindowLimiter creates a SlidingWindowLimiter.
+func NewSlidingWindowLimiter(config LimiterConfig) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{newStoreLimiter(config)}
+}
+
+type slidingWindowState struct {
+	Start    int64 `json:"s"`
+	Current  int   `json:"c"`
+	Previous int   `json:"p"`
+}
+
+// AllowN implements Limiter.
+func (l *SlidingWindowLimiter) AllowN(ctx context.Context, key string, n int) (info RateLimitInfo, allowed bool, err error) {
+	now := l.now()
+	window := l.config.Window
+	start := now.Truncate(window)
+	err = updateState(ctx, l.storeLimiter, key, 2*window, func(state *slidingWindowState) {
+		switch state.Start {
+		case start.UnixNano():
+		case start.Add(-window).UnixNano():
+			*state = slidingWindowState{Start: start.UnixNano(), Previous: state.Current}
+		default:
+			*state = slidingWindowState{Start: start.UnixNano()}
+		}
+
+		elapsed := now.Sub(start)
+		weight := 1 - float64(elapsed)/float64(window)
+		limit := float64(l.config.Limit)
+		estimate := float64(state.Previous)*weight + float64(state.Current)
+		allowed = estimate+float64(n) <= limit
+		if allowed {
+			state.Current += n
+			estimate += float64(n)
+		}
+
+		info = RateLimitInfo{Limit: l.config.Limit, Remaining: int(math.Max(0, math.Floor(limit-estimate)))}
+		switch {
+		case state.Current > 0:
+			info.Reset = window - elapsed + window
+		case state.Previous > 0:
+			info.Reset = window - elapsed
+		}
+		if !allowed && n <= l.config.Limit {
+			info.RetryAfter = slidingRetryAfter(state, float64(n), limit, elapsed, window)
+		}
+	})
+	return info, allowed, err
+}
+
+// slidingRetryAfter returns how long until the estimate leaves room for n.
+func slidingRetryAfter(state *slidingWindowState, n, limit float64, elapsed, window time.Duration) time.Duration {
+	// The previous window's weight may fade enough within the current window.
+	if room := limit - float64(state.Current) - n; room >= 0 && state.Previous > 0 {
+		at := time.Duration(math.Ceil((1 - room/float64(state.Previous)) * float64(window)))
+		return max(at-elapsed, time.Nanosecond)
+	}
+	// Otherwise the current count must fade during the next window.
+	at := time.Duration(math.Ceil((1 - (limit-n)/float64(state.Current)) * float64(window)))
+	return window - elapsed + max(at, 0)
+}
+
+// GCRALimiter implements the generic cell rate algorithm: requests are spaced
+// Window/Limit apart, with bursts of up to Limit. It stores one timestamp per key.
+type GCRALimiter struct {
+	storeLimiter
+}
+
+// NewGCRALimiter creates a GCRALimiter.
+func NewGCRALimiter(config LimiterConfig) *GCRALimiter {
+	return &GCRALimiter{newStoreLimiter(config)}
+}
+
+// AllowN implements Limiter.
+func (l *GCRALimiter) AllowN(ctx context.Context, key string, n int) (info RateLimitInfo, allowed bool, err error) {
+	now := l.now()
+	window := l.config.Window
+	interval := window / time.Duration(l.config.Limit)
+	err = updateState(ctx, l.storeLimiter, key, window, func(tatNanos *int64) {
+		// The theoretical arrival time is when the key would be idle again.
+		tat := time.Unix(0, *tatNanos)
+		if tat.Before(now) {
+			tat = now
+		}
+		newTAT := tat.Add(time.Duration(n) * interval)
+		allowAt := newTAT.Add(-window)
+
+		allowed = !now.Before(allowAt)
+		if allowed {
+			tat = newTAT
+			*tatNanos = tat.UnixNano()
+		}
+		info = RateLimitInfo{
+			Limit:     l.config.Limit,
+			Remaining: int(max(0, (window-tat.Sub(now))/interval)),
+			Reset:     tat.Sub(now),
+		}
+		if !allowed && n <= l.config.Limit {
+			info.RetryAfter = allowAt.Sub(now)
+		}
+	})
+	return info, allowed, err
+}
+
+// AllowN implements Limiter with the key's token bucket.
+func (l *KeyedRateLimiter) AllowN(ctx context.Context, key string, n int) (RateLimitInfo, bool, error) {
+	now := l.now()
+	limiter := l.bucket(key, now)
+	reservation := limiter.ReserveN(now, n)
+	if !reservation.OK() {
+		return RateLimitInfo{Limit: limiter.Burst()}, false, nil
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		info := bucketInfo(limiter, now)
+		info.RetryAfter = delay
+		return info, false, nil
+	}
+	return bucketInfo(limiter, now), true, nil
+}
+
+// LimitOptions represents configuration options for LimitRequests.
+type LimitOptions struct {
+	Key            KeyFunc                // Defaults to KeyByIP
+	OnReject       RateLimitRejectHandler // Defaults to DefaultRateLimitRejectHandler
+	DisableHeaders bool                   // Omit the RateLimit-* and Retry-After headers
+	FailClosed     bool                   // Reject requests when the limiter fails instead of allowing them
+}
+
+// LimitRequests is a middleware applying limiter to each request's key.
+// Limiter errors, such as an unreachable Store, are logged and the request
+// is allowed unless FailClosed is set.
+func LimitRequests(limiter Limiter, opts LimitOptions) Middleware {
+	if opts.Key == nil {
+		opts.Key = KeyByIP
+	}
+	policy := newRateLimitPolicy(opts.OnReject, false, 0, opts.DisableHeaders)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := opts.Key(req)
+			if key == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			info, allowed, err := limiter.AllowN(req.Context(), key, 1)
+			if err != nil {
+				logRequestError(req, err, slog.LevelError)
+				if opts.FailClosed {
+					RenderError(w, req, NewHTTPError(http.StatusServiceUnavailable, "Rate limiter unavailable"))
+				} else {
+					next.ServeHTTP(w, req)
+				}
+				return
+			}
+			if !allowed {
+				policy.reject(w, req, info)
+				return
+			}
+			if policy.headers {
+				setRateLimitHeaders(w.Header(), info)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
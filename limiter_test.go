@@ -0,0 +1,177 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var _ Limiter = (*KeyedRateLimiter)(nil)
+
+// step calls AllowN for "k" and checks the outcome and retry delay.
+func step(t *testing.T, limiter Limiter, allowed bool, retryAfter time.Duration) RateLimitInfo {
+	t.Helper()
+	info, ok, err := limiter.AllowN(context.Background(), "k", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok != allowed || info.RetryAfter != retryAfter {
+		t.Errorf("Expected allowed=%v retry after %v, got allowed=%v %+v", allowed, retryAfter, ok, info)
+	}
+	return info
+}
+
+func TestFixedWindowLimiter(t *testing.T) {
+	now := time.Unix(600, 0)
+	limiter := NewFixedWindowLimiter(LimiterConfig{Limit: 2, Window: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	if info := step(t, limiter, true, 0); info.Remaining != 1 || info.Reset != time.Minute {
+		t.Errorf("Unexpected info %+v", info)
+	}
+	now = now.Add(10 * time.Second)
+	step(t, limiter, true, 0)
+	step(t, limiter, false, 50*time.Second)
+
+	now = now.Add(50 * time.Second)
+	step(t, limiter, true, 0)
+}
+
+func TestSlidingLogLimiter(t *testing.T) {
+	now := time.Unix(600, 0)
+	limiter := NewSlidingLogLimiter(LimiterConfig{Limit: 2, Window: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	step(t, limiter, true, 0)
+	now = now.Add(30 * time.Second)
+	step(t, limiter, true, 0)
+	now = now.Add(10 * time.Second)
+	step(t, limiter, false, 20*time.Second)
+
+	// Only the first request has left the window.
+	now = now.Add(20 * time.Second)
+	step(t, limiter, true, 0)
+	step(t, limiter, false, 30*time.Second)
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	now := time.Unix(600, 0)
+	limiter := NewSlidingWindowLimiter(LimiterConfig{Limit: 10, Window: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		step(t, limiter, true, 0)
+	}
+	step(t, limiter, false, 66*time.Second)
+
+	// Halfway into the next window the previous one counts for half.
+	now = now.Add(90 * time.Second)
+	for i := 0; i < 5; i++ {
+		step(t, limiter, true, 0)
+	}
+	step(t, limiter, false, 6*time.Second)
+
+	now = now.Add(6 * time.Second)
+	step(t, limiter, true, 0)
+}
+
+func TestGCRALimiter(t *testing.T) {
+	now := time.Unix(600, 0)
+	limiter := NewGCRALimiter(LimiterConfig{Limit: 2, Window: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	if info := step(t, limiter, true, 0); info.Remaining != 1 || info.Reset != 30*time.Second {
+		t.Errorf("Unexpected info %+v", info)
+	}
+	step(t, limiter, true, 0)
+	step(t, limiter, false, 30*time.Second)
+
+	// Requests are spaced Window/Limit apart once the burst is used.
+	now = now.Add(30 * time.Second)
+	step(t, limiter, true, 0)
+	step(t, limiter, false, 30*time.Second)
+}
+
+func TestLimitersShareStore(t *testing.T) {
+	store := NewFileStore(t.TempDir() + "/limits.json")
+	replicas := []Limiter{
+		NewGCRALimiter(LimiterConfig{Limit: 3, Window: time.Minute, Store: store}),
+		NewGCRALimiter(LimiterConfig{Limit: 3, Window: time.Minute, Store: store}),
+	}
+
+	var allowed int
+	for i := 0; i < 6; i++ {
+		_, ok, err := replicas[i%2].AllowN(context.Background(), "client", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("Expected replicas to enforce one limit of 3, got %d allowed", allowed)
+	}
+
+	other := NewGCRALimiter(LimiterConfig{Limit: 3, Window: time.Minute, Store: store, Prefix: "other:"})
+	if _, ok, _ := other.AllowN(context.Background(), "client", 1); !ok {
+		t.Error("Expected a limiter with another prefix to keep separate state")
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Update(ctx context.Context, key string, ttl time.Duration, fn func([]byte) ([]byte, error)) error {
+	return errors.New("store unavailable")
+}
+
+func TestLimitRequests(t *testing.T) {
+	handler := LimitRequests(NewFixedWindowLimiter(LimiterConfig{Limit: 1, Window: time.Hour}), LimitOptions{})(okHandler)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, requestFrom("10.0.0.1:1", "/"))
+	checkResponse(t, recorder, http.StatusOK, "ok", "RateLimit-Remaining", "0")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, requestFrom("10.0.0.1:1", "/"))
+	checkResponse(t, recorder, http.StatusTooManyRequests, `{"error":"Too many requests"}`+"\n", "RateLimit-Limit", "1")
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, requestFrom("10.0.0.2:1", "/"))
+	checkResponse(t, recorder, http.StatusOK, "ok", "", "")
+}
+
+func TestLimitRequestsRouterUse(t *testing.T) {
+	router := NewRouter()
+	router.Use(LimitRequests(NewFixedWindowLimiter(LimiterConfig{Limit: 2, Window: time.Hour}), LimitOptions{}))
+	router.AddRoute(http.MethodGet, "/", okHandler)
+
+	for _, remaining := range []string{"1", "0"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, requestFrom("10.0.0.1:1", "/"))
+		checkResponse(t, recorder, http.StatusOK, "ok", "RateLimit-Remaining", remaining)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, requestFrom("10.0.0.1:1", "/"))
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the third request to be limited, got %d", recorder.Code)
+	}
+}
+
+func TestLimitRequestsStoreFailure(t *testing.T) {
+	limiter := NewFixedWindowLimiter(LimiterConfig{Limit: 1, Window: time.Hour, Store: failingStore{}})
+
+	recorder := httptest.NewRecorder()
+	LimitRequests(limiter, LimitOptions{})(okHandler).ServeHTTP(recorder, requestFrom("10.0.0.1:1", "/"))
+	checkResponse(t, recorder, http.StatusOK, "ok", "", "")
+
+	recorder = httptest.NewRecorder()
+	LimitRequests(limiter, LimitOptions{FailClosed: true})(okHandler).ServeHTTP(recorder, requestFrom("10.0.0.1:1", "/"))
+	checkResponse(t, recorder, http.StatusServiceUnavailable, `{"error":"Rate limiter unavailable"}`+"\n", "", "")
+}
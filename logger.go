@@ -1,20 +1,130 @@
 package gorouter
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
-
-	"github.com/sirupsen/logrus"
 )
 
-var golog = logrus.New()
+// Logger is the logging interface used by all gorouter components. The
+// default logs through slog.Default(); use SlogLogger for a configured
+// *slog.Logger, or implement Logger to adapt another logging library.
+type Logger interface {
+	// Log writes a record at level. args are alternating keys and values,
+	// or slog.Attr values, as with slog.
+	Log(ctx context.Context, level slog.Level, msg string, args ...interface{})
+	// With returns a Logger adding args to every record.
+	With(args ...interface{}) Logger
+}
+
+// SlogLogger adapts a *slog.Logger to the Logger interface. A nil logger
+// uses slog.Default() at the time of each call.
+func SlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l.slog().Log(ctx, level, msg, args...)
+}
+
+func (l slogLogger) With(args ...interface{}) Logger {
+	return slogLogger{l.slog().With(args...)}
+}
+
+func (l slogLogger) slog() *slog.Logger {
+	if l.logger == nil {
+		return slog.Default()
+	}
+	return l.logger
+}
+
+// SetLogger sets the logger used for requests served by the router.
+func (r *Router) SetLogger(logger Logger) {
+	r.logger = logger
+}
 
+// Logger returns the router's logger, or the default logger if none is set.
+func (r *Router) Logger() Logger {
+	if r.logger == nil {
+		return defaultLogger
+	}
+	return r.logger
+}
+
+// defaultLogger is used outside a Router without a Logger of its own.
+var defaultLogger = SlogLogger(nil)
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, which LoggerFrom then
+// returns instead of the Router's.
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFrom returns the request-scoped logger: the logger set with
+// WithLogger, the Router's or the default, enriched with the request ID,
+// the matched route pattern and the user when they are known.
+func LoggerFrom(ctx context.Context) Logger {
+	if ctx == nil {
+		return defaultLogger
+	}
+	logger, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		logger = defaultLogger
+		if r := routerFromContext(ctx); r != nil && r.logger != nil {
+			logger = r.logger
+		}
+	}
+
+	var args []interface{}
+	if id := RequestIDFromContext(ctx); id != "" {
+		args = append(args, "request_id", id)
+	}
+	if info, ok := ctx.Value(routeInfoKey{}).(*routeInfo); ok && info.pattern != "" {
+		args = append(args, "route", info.pattern)
+	}
+	if user := UserFromContext(ctx); user != "" {
+		args = append(args, "user", user)
+	}
+	if len(args) == 0 {
+		return logger
+	}
+	return logger.With(args...)
+}
+
+// RequestLogger is a middleware logging each request as it is received.
+// Use AccessLog to log requests with their outcome once they complete.
 func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		golog.WithFields(logrus.Fields{
-			"method": req.Method,
-			"url":    req.URL.Path,
-			"remote": req.RemoteAddr,
-		}).Info("Received request")
+		LoggerFrom(req.Context()).Log(req.Context(), slog.LevelInfo, "Received request",
+			"method", req.Method,
+			"url", req.URL.Path,
+			"remote", req.RemoteAddr,
+		)
 		next.ServeHTTP(w, req)
 	})
 }
+
+// logWriter adapts a Logger to an io.Writer for the standard log package,
+// as used by http.Server.ErrorLog.
+type logWriter struct {
+	logger Logger
+	level  slog.Level
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	msg := string(p)
+	if len(msg) > 0 && msg[len(msg)-1] == '\n' {
+		msg = msg[:len(msg)-1]
+	}
+	w.logger.Log(context.Background(), w.level, msg)
+	return len(p), nil
+}
@@ -0,0 +1,90 @@
+package gorouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newBufferLogger returns a Logger writing JSON records to a buffer.
+func newBufferLogger() (Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return SlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))), &buf
+}
+
+// decodeRecords parses the JSON lines written by a buffer logger.
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("Invalid log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRouterLoggerRequestScope(t *testing.T) {
+	logger, buf := newBufferLogger()
+	router := NewRouter()
+	router.SetLogger(logger)
+	router.Use(RequestID)
+
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, WithUser(r, "alice"))
+		})
+	}
+	router.AddRoute(http.MethodGet, "/items/:id", Adapt(func(w http.ResponseWriter, r *http.Request) error {
+		LoggerFrom(r.Context()).Log(r.Context(), slog.LevelInfo, "Loading item")
+		return errors.New("database down")
+	}), authenticate)
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	checkResponse(t, recorder, http.StatusInternalServerError, `{"error":"Internal Server Error"}`+"\n", RequestIDHeader, "req-1")
+
+	records := decodeRecords(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log records, got %d: %s", len(records), buf)
+	}
+	for _, record := range records {
+		if record["request_id"] != "req-1" || record["route"] != "/items/:id" || record["user"] != "alice" {
+			t.Errorf("Expected request-scoped attributes, got %v", record)
+		}
+	}
+	if records[1]["msg"] != "Request failed" || records[1]["error"] != "database down" || records[1]["level"] != "ERROR" {
+		t.Errorf("Unexpected error record %v", records[1])
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	logger, buf := newBufferLogger()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := WithLogger(req.Context(), logger.With("tenant", "acme"))
+
+	LoggerFrom(ctx).Log(ctx, slog.LevelWarn, "hello")
+	records := decodeRecords(t, buf)
+	if len(records) != 1 || records[0]["tenant"] != "acme" || records[0]["msg"] != "hello" {
+		t.Errorf("Unexpected records %v", records)
+	}
+}
+
+func TestServerLogger(t *testing.T) {
+	logger, buf := newBufferLogger()
+	server := NewServer(nil, ServerConfig{Addr: ":0", Logger: logger})
+	server.ErrorLog.Print("http: TLS handshake error")
+
+	records := decodeRecords(t, buf)
+	if len(records) != 1 || records[0]["msg"] != "http: TLS handshake error" || records[0]["level"] != "ERROR" {
+		t.Errorf("Expected http.Server errors to use the logger, got %v", records)
+	}
+}
@@ -0,0 +1,103 @@
+package gorouter
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a single media range of an Accept header.
+type acceptRange struct {
+	mediaType string  // e.g. "application/json", "text/*" or "*/*"
+	q         float64 // quality value between 0 and 1
+	index     int     // position in the header, used to break ties
+}
+
+// parseAccept parses an Accept header into media ranges. Malformed entries are skipped.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for i, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				continue
+			}
+			q = parsed
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q, index: i})
+	}
+	return ranges
+}
+
+// match reports whether the range covers the media type and how specific the match is.
+func (ar acceptRange) match(mediaType string) (int, bool) {
+	if ar.mediaType == mediaType {
+		return 2, true
+	}
+	if ar.mediaType == "*/*" {
+		return 0, true
+	}
+	if prefix, ok := strings.CutSuffix(ar.mediaType, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+		return 1, true
+	}
+	return 0, false
+}
+
+// negotiate returns the index of the offered media type preferred by the
+// Accept header, or -1 if none is acceptable. An empty header accepts the
+// first offer. Ties are broken by specificity, then by the client's order,
+// then by the order of the offers.
+func negotiate(header string, offers []string) int {
+	if len(offers) == 0 {
+		return -1
+	}
+	if strings.TrimSpace(header) == "" {
+		return 0
+	}
+	ranges := parseAccept(header)
+
+	type candidate struct {
+		offer       int
+		q           float64
+		specificity int
+		index       int
+	}
+	var candidates []candidate
+	for i, offer := range offers {
+		best := candidate{offer: i, specificity: -1}
+		for _, ar := range ranges {
+			// The most specific matching range determines the quality.
+			if specificity, ok := ar.match(offer); ok && specificity > best.specificity {
+				best.q, best.specificity, best.index = ar.q, specificity, ar.index
+			}
+		}
+		if best.specificity >= 0 && best.q > 0 {
+			candidates = append(candidates, best)
+		}
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.q != b.q {
+			return a.q > b.q
+		}
+		if a.specificity != b.specificity {
+			return a.specificity > b.specificity
+		}
+		return a.index < b.index
+	})
+	return candidates[0].offer
+}
@@ -0,0 +1,189 @@
+package gorouter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/saadi925/gorouter/validation"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object. Extension members are
+// serialized next to the standard members.
+type Problem struct {
+	Type       string                 // URI reference identifying the problem type
+	Title      string                 // Short, human-readable summary of the problem type
+	Status     int                    // HTTP status code
+	Detail     string                 // Explanation specific to this occurrence
+	Instance   string                 // URI reference identifying this occurrence
+	Extensions map[string]interface{} // Additional members
+}
+
+// NewProblem creates a Problem of type "about:blank" titled after the status code.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// With sets an extension member and returns the Problem.
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// Error implements the error interface, so handlers can return a Problem directly.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// MarshalJSON implements json.Marshaler. Extensions never override standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	if p.Type != "" {
+		members["type"] = p.Type
+	}
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Unknown members are kept as extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*p = Problem{}
+	for key, raw := range members {
+		var err error
+		switch key {
+		case "type":
+			err = json.Unmarshal(raw, &p.Type)
+		case "title":
+			err = json.Unmarshal(raw, &p.Title)
+		case "status":
+			err = json.Unmarshal(raw, &p.Status)
+		case "detail":
+			err = json.Unmarshal(raw, &p.Detail)
+		case "instance":
+			err = json.Unmarshal(raw, &p.Instance)
+		default:
+			var value interface{}
+			err = json.Unmarshal(raw, &value)
+			p.With(key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteProblem writes the Problem as application/problem+json. A Problem
+// without a Status is written as a 500.
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	if p.Status == 0 {
+		withStatus := *p
+		withStatus.Status = http.StatusInternalServerError
+		p = &withStatus
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(append(body, '\n'))
+}
+
+// InvalidParam is an entry of the "invalid-params" extension member.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ProblemFromError converts an error into a Problem. Problems are returned
+// as is, HTTPErrors and body decoding failures keep their status and message,
+// validation.Errors become a 422 problem with an "invalid-params" extension,
+// and any other error becomes an opaque 500.
+func ProblemFromError(err error) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	var validationErrors validation.Errors
+	if errors.As(err, &validationErrors) && !errors.As(err, new(*HTTPError)) {
+		problem = NewProblem(http.StatusUnprocessableEntity, "The request parameters failed validation.")
+		return problem.With("invalid-params", invalidParams(validationErrors))
+	}
+
+	httpErr := toHTTPError(err)
+	problem = NewProblem(httpErr.Status, "")
+	if httpErr.Type != "" {
+		problem.Type = httpErr.Type
+	}
+	if httpErr.Message != problem.Title {
+		problem.Detail = httpErr.Message
+	}
+	if httpErr.Code != "" {
+		problem.With("code", httpErr.Code)
+	}
+	if validationErrors != nil {
+		problem.With("invalid-params", invalidParams(validationErrors))
+	} else if httpErr.Details != nil {
+		problem.With("details", httpErr.Details)
+	}
+	return problem
+}
+
+// invalidParams converts validation failures into "invalid-params" entries.
+func invalidParams(validationErrors validation.Errors) []InvalidParam {
+	params := make([]InvalidParam, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		params = append(params, InvalidParam{Name: fe.Field, Reason: fe.Message})
+	}
+	return params
+}
+
+// ProblemErrorRenderer is an ErrorRenderer that writes every error, including
+// the router's 404, 405 and panic responses, as RFC 9457 problem details:
+//
+//	router.SetErrorRenderer(gorouter.ProblemErrorRenderer)
+func ProblemErrorRenderer(w http.ResponseWriter, req *http.Request, err error) {
+	problem := ProblemFromError(err)
+	if problem.Instance == "" {
+		clone := *problem
+		clone.Instance = req.URL.Path
+		problem = &clone
+	}
+	WriteProblem(w, problem)
+}
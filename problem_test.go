@@ -0,0 +1,92 @@
+package gorouter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/saadi925/gorouter/validation"
+)
+
+func decodeProblem(t *testing.T, recorder *httptest.ResponseRecorder) *Problem {
+	t.Helper()
+	if ct := recorder.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Fatalf("Expected Content-Type %s, got %s", ProblemContentType, ct)
+	}
+	var problem Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	return &problem
+}
+
+func TestProblemMarshalJSON(t *testing.T) {
+	problem := NewProblem(http.StatusForbidden, "Your balance is 30").With("balance", 30).With("status", 999)
+	problem.Type = "https://example.com/probs/out-of-credit"
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		t.Fatalf("Failed to marshal problem: %v", err)
+	}
+	expected := `{"balance":30,"detail":"Your balance is 30","status":403,"title":"Forbidden","type":"https://example.com/probs/out-of-credit"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestWriteProblemWithoutStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	WriteProblem(recorder, &Problem{Title: "Unknown"})
+	if problem := decodeProblem(t, recorder); recorder.Code != http.StatusInternalServerError || problem.Status != http.StatusInternalServerError {
+		t.Errorf("Expected a Problem without status to be written as 500, got %d", recorder.Code)
+	}
+}
+
+func TestProblemErrorRenderer(t *testing.T) {
+	type signup struct {
+		Email string `validate:"required,email"`
+	}
+
+	r := NewRouter()
+	r.SetErrorRenderer(ProblemErrorRenderer)
+	r.Use(ErrorHandler)
+	r.AddRoute(http.MethodPost, "/signup", Adapt(func(w http.ResponseWriter, req *http.Request) error {
+		return validation.NewValidator().ValidateStruct(signup{Email: "nope"})
+	}))
+	r.AddRoute(http.MethodGet, "/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/signup", nil))
+	problem := decodeProblem(t, recorder)
+	if problem.Status != http.StatusUnprocessableEntity || recorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, problem.Status)
+	}
+	params, _ := problem.Extensions["invalid-params"].([]interface{})
+	if len(params) != 1 || params[0].(map[string]interface{})["name"] != "Email" {
+		t.Errorf("Expected invalid-params for Email, got %v", problem.Extensions["invalid-params"])
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/signup", nil))
+	problem = decodeProblem(t, recorder)
+	if problem.Status != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("Expected 405 allowing POST, got %d allowing %q", problem.Status, recorder.Header().Get("Allow"))
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	problem = decodeProblem(t, recorder)
+	if problem.Status != http.StatusNotFound || problem.Instance != "/nowhere" {
+		t.Errorf("Expected 404 for /nowhere, got %d for %s", problem.Status, problem.Instance)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	problem = decodeProblem(t, recorder)
+	if problem.Status != http.StatusInternalServerError || problem.Detail != "" {
+		t.Errorf("Expected opaque 500, got %d with detail %q", problem.Status, problem.Detail)
+	}
+}
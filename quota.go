@@ -0,0 +1,263 @@
+package gorouter
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// QuotaConfig represents configuration options for a Quota.
+type QuotaConfig struct {
+	Daily       int64                                                            // Cost allowed per consumer per day; zero means no daily quota
+	Monthly     int64                                                            // Cost allowed per consumer per month; zero means no monthly quota
+	Key         KeyFunc                                                          // Identifies the consumer; defaults to KeyByUser, falling back to KeyByIP
+	Store       Store                                                            // Defaults to a new MemoryStore; share a backend Store across replicas
+	Prefix      string                                                           // Prepended to keys so several quotas can share a Store
+	DefaultCost int64                                                            // Cost of requests without a declared cost; defaults to 1
+	Location    *time.Location                                                   // Where days and months start; defaults to UTC
+	OnExceeded  func(w http.ResponseWriter, req *http.Request, usage QuotaUsage) // Defaults to a 429 error
+	FailClosed  bool                                                             // Reject requests when the Store fails instead of allowing them
+}
+
+// QuotaUsage is a consumer's usage of a Quota.
+type QuotaUsage struct {
+	Consumer string       `json:"consumer"`
+	Daily    *PeriodUsage `json:"daily,omitempty"`
+	Monthly  *PeriodUsage `json:"monthly,omitempty"`
+}
+
+// PeriodUsage is the usage of a quota period.
+type PeriodUsage struct {
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// exceeded reports whether cost more does not fit in the period.
+func (u *PeriodUsage) exceeded(cost int64) bool {
+	return u != nil && u.Used+cost > u.Limit
+}
+
+// Quota enforces daily and monthly budgets per consumer, where each request
+// is charged the cost declared for its route.
+type Quota struct {
+	config QuotaConfig
+	now    func() time.Time
+}
+
+// quotaState is the stored usage of a consumer.
+type quotaState struct {
+	Day       string `json:"d,omitempty"`
+	DayUsed   int64  `json:"du,omitempty"`
+	Month     string `json:"m,omitempty"`
+	MonthUsed int64  `json:"mu,omitempty"`
+}
+
+// quotaCharge is what a request has been charged by a Quota. It is shared
+// by the Quota's middleware on the request, so that the first Cost replaces,
+// rather than adds to, the cost charged by Limit or by inner Cost middleware.
+type quotaCharge struct {
+	cost     int64
+	declared bool // Charged by Cost rather than Limit
+}
+
+type quotaChargeKey struct{ quota *Quota }
+
+// NewQuota creates a Quota.
+func NewQuota(config QuotaConfig) *Quota {
+	if config.Key == nil {
+		config.Key = FirstKey(KeyByUser, KeyByIP)
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.DefaultCost <= 0 {
+		config.DefaultCost = 1
+	}
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+	return &Quota{config: config, now: time.Now}
+}
+
+// Charge adds cost to the consumer's usage if it fits in every period, and
+// reports whether it did. The returned usage includes the charge. A negative
+// cost refunds an earlier charge.
+func (q *Quota) Charge(ctx context.Context, consumer string, cost int64) (usage QuotaUsage, charged bool, err error) {
+	err = q.update(ctx, consumer, func(state *quotaState, now time.Time) {
+		usage = q.usage(consumer, state, now)
+		if usage.Daily.exceeded(cost) || usage.Monthly.exceeded(cost) {
+			return
+		}
+		// A refund may follow the start of a new period.
+		state.DayUsed = max(0, state.DayUsed+cost)
+		state.MonthUsed = max(0, state.MonthUsed+cost)
+		usage, charged = q.usage(consumer, state, now), true
+	})
+	return usage, charged, err
+}
+
+// Usage returns the consumer's current usage, for dashboards and usage APIs.
+func (q *Quota) Usage(ctx context.Context, consumer string) (usage QuotaUsage, err error) {
+	err = q.update(ctx, consumer, func(state *quotaState, now time.Time) {
+		usage = q.usage(consumer, state, now)
+	})
+	return usage, err
+}
+
+// update applies fn to the consumer's state for the current periods.
+func (q *Quota) update(ctx context.Context, consumer string, fn func(state *quotaState, now time.Time)) error {
+	now := q.now().In(q.config.Location)
+	day, month := now.Format(time.DateOnly), now.Format("2006-01")
+	// Keep the state until the month is over.
+	ttl := monthStart(now).AddDate(0, 1, 0).Sub(now)
+
+	return q.config.Store.Update(ctx, q.config.Prefix+consumer, ttl, func(data []byte) ([]byte, error) {
+		var state quotaState
+		if data != nil {
+			if err := json.Unmarshal(data, &state); err != nil {
+				return nil, err
+			}
+		}
+		if state.Day != day {
+			state.Day, state.DayUsed = day, 0
+		}
+		if state.Month != month {
+			state.Month, state.MonthUsed = month, 0
+		}
+		fn(&state, now)
+		return json.Marshal(state)
+	})
+}
+
+// usage describes state for the periods configured.
+func (q *Quota) usage(consumer string, state *quotaState, now time.Time) QuotaUsage {
+	usage := QuotaUsage{Consumer: consumer}
+	if q.config.Daily > 0 {
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		usage.Daily = periodUsage(q.config.Daily, state.DayUsed, dayStart.AddDate(0, 0, 1))
+	}
+	if q.config.Monthly > 0 {
+		usage.Monthly = periodUsage(q.config.Monthly, state.MonthUsed, monthStart(now).AddDate(0, 1, 0))
+	}
+	return usage
+}
+
+func periodUsage(limit, used int64, reset time.Time) *PeriodUsage {
+	return &PeriodUsage{Limit: limit, Used: used, Remaining: max(0, limit-used), Reset: reset}
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Limit is a middleware charging each request the DefaultCost, unless a Cost
+// of the same Quota applies to it.
+func (q *Quota) Limit(next http.Handler) http.Handler {
+	return q.charge(q.config.DefaultCost, false)(next)
+}
+
+// Cost returns a middleware charging each request cost, for declaring the
+// weight of expensive routes at registration. It overrides Limit wherever
+// Limit is installed, so Router.Use(quota.Limit) charges the DefaultCost
+// except on routes with a Cost. When several Costs of a Quota apply, the
+// outermost wins; route middleware runs outside RouteGroup middleware, so a
+// route's Cost overrides the group's.
+func (q *Quota) Cost(cost int64) Middleware {
+	return q.charge(cost, true)
+}
+
+// charge returns the middleware of Limit and Cost.
+func (q *Quota) charge(cost int64, declared bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			consumer := q.config.Key(req)
+			if consumer == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			charge, _ := req.Context().Value(quotaChargeKey{q}).(*quotaCharge)
+			if charge == nil {
+				charge = new(quotaCharge)
+				req = req.WithContext(context.WithValue(req.Context(), quotaChargeKey{q}, charge))
+			} else if charge.declared || !declared {
+				// An outer Cost, or a Limit, already settled the charge.
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			// Only charge the difference from what an outer Limit charged.
+			usage, charged, err := q.Charge(req.Context(), consumer, cost-charge.cost)
+			if err != nil {
+				logRequestError(req, err, slog.LevelError)
+				if q.config.FailClosed {
+					RenderError(w, req, NewHTTPError(http.StatusServiceUnavailable, "Quota unavailable"))
+				} else {
+					next.ServeHTTP(w, req)
+				}
+				return
+			}
+			if !charged {
+				// Refund the outer charge of the rejected request.
+				if charge.cost != 0 {
+					if refunded, _, err := q.Charge(req.Context(), consumer, -charge.cost); err != nil {
+						logRequestError(req, err, slog.LevelError)
+					} else {
+						usage = refunded
+					}
+				}
+				q.exceeded(w, req, usage, cost)
+				return
+			}
+			charge.cost, charge.declared = cost, declared
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// exceeded writes the response for a request over its quota.
+func (q *Quota) exceeded(w http.ResponseWriter, req *http.Request, usage QuotaUsage, cost int64) {
+	// Retry once the exceeded periods have reset.
+	var reset time.Time
+	for _, period := range []*PeriodUsage{usage.Daily, usage.Monthly} {
+		if period.exceeded(cost) && period.Reset.After(reset) {
+			reset = period.Reset
+		}
+	}
+	if retryAfter := reset.Sub(q.now()); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(retryAfter), 10))
+	}
+
+	if q.config.OnExceeded != nil {
+		q.config.OnExceeded(w, req, usage)
+		return
+	}
+	RenderError(w, req, &HTTPError{Status: http.StatusTooManyRequests, Message: "Quota exceeded", Details: usage})
+}
+
+// UsageHandler serves the usage of the consumer returned by consumer, which
+// defaults to the Quota's Key so clients see their own usage. Pass a
+// function reading e.g. a query parameter for admin dashboards, and protect
+// the route accordingly.
+func (q *Quota) UsageHandler(consumer KeyFunc) http.HandlerFunc {
+	if consumer == nil {
+		consumer = q.config.Key
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		key := consumer(req)
+		if key == "" {
+			RenderError(w, req, NewHTTPError(http.StatusBadRequest, "Unknown consumer"))
+			return
+		}
+		usage, err := q.Usage(req.Context(), key)
+		if err != nil {
+			RenderError(w, req, err)
+			return
+		}
+		Render(w, req, usage, http.StatusOK)
+	}
+}
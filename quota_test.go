@@ -0,0 +1,143 @@
+package gorouter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuotaRouteCosts(t *testing.T) {
+	now := time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)
+	quota := NewQuota(QuotaConfig{Daily: 12, Monthly: 15})
+	quota.now = func() time.Time { return now }
+
+	router := NewRouter()
+	api := router.Group("/api")
+	api.Use(quota.Limit)
+	api.GET("/items", okHandler.ServeHTTP)
+	api.GET("/export", okHandler.ServeHTTP, quota.Cost(5))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, WithUser(httptest.NewRequest(http.MethodGet, path, nil), "alice"))
+		return recorder
+	}
+
+	serve("/api/export")
+	serve("/api/export")
+	serve("/api/items")
+	usage, _ := quota.Usage(context.Background(), "alice")
+	if usage.Daily.Used != 11 {
+		t.Errorf("Expected the export cost to replace the default cost, got %+v", usage.Daily)
+	}
+
+	recorder := serve("/api/items")
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected a cheap request to fit, got %d", recorder.Code)
+	}
+	recorder = serve("/api/export")
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") != "3600" {
+		t.Errorf("Expected the daily quota to be exceeded until midnight, got %d %v", recorder.Code, recorder.Header())
+	}
+
+	// A new day resets the daily quota but not the monthly one.
+	now = now.Add(2 * time.Hour)
+	usage, _ = quota.Usage(context.Background(), "alice")
+	if usage.Daily.Used != 0 || usage.Monthly.Used != 0 {
+		t.Errorf("Expected both periods to reset on the first of the month, got %+v %+v", usage.Daily, usage.Monthly)
+	}
+}
+
+func TestQuotaRouterUse(t *testing.T) {
+	quota := NewQuota(QuotaConfig{Daily: 2})
+	router := NewRouter()
+	router.Use(quota.Limit)
+	router.AddRoute(http.MethodGet, "/", okHandler.ServeHTTP)
+
+	for _, status := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, WithUser(httptest.NewRequest(http.MethodGet, "/", nil), "alice"))
+		if recorder.Code != status {
+			t.Errorf("Expected status %d, got %d", status, recorder.Code)
+		}
+	}
+}
+
+func TestQuotaRouterUseWithRouteCost(t *testing.T) {
+	quota := NewQuota(QuotaConfig{Daily: 60})
+	router := NewRouter()
+	router.Use(quota.Limit)
+	router.AddRoute(http.MethodGet, "/items", okHandler.ServeHTTP)
+	router.AddRoute(http.MethodGet, "/export", okHandler.ServeHTTP, quota.Cost(50))
+
+	serve := func(path string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, WithUser(httptest.NewRequest(http.MethodGet, path, nil), "alice"))
+		return recorder.Code
+	}
+
+	serve("/export")
+	serve("/items")
+	usage, _ := quota.Usage(context.Background(), "alice")
+	if usage.Daily.Used != 51 {
+		t.Errorf("Expected the export cost to replace the default cost, got %+v", usage.Daily)
+	}
+
+	if code := serve("/export"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected an export over the quota to be rejected, got %d", code)
+	}
+	usage, _ = quota.Usage(context.Background(), "alice")
+	if usage.Daily.Used != 51 {
+		t.Errorf("Expected a rejected export not to be charged, got %+v", usage.Daily)
+	}
+}
+
+func TestQuotaMonthly(t *testing.T) {
+	now := time.Date(2024, 4, 10, 12, 0, 0, 0, time.UTC)
+	quota := NewQuota(QuotaConfig{Daily: 10, Monthly: 15})
+	quota.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, charged, _ := quota.Charge(ctx, "bob", 10); !charged {
+		t.Fatal("Expected the charge to fit")
+	}
+	now = now.AddDate(0, 0, 1)
+	usage, charged, _ := quota.Charge(ctx, "bob", 6)
+	if charged {
+		t.Error("Expected the monthly quota to be exceeded")
+	}
+	if usage.Daily.Remaining != 10 || usage.Monthly.Remaining != 5 {
+		t.Errorf("Expected a rejected charge to leave usage unchanged, got %+v %+v", usage.Daily, usage.Monthly)
+	}
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC); !usage.Monthly.Reset.Equal(want) {
+		t.Errorf("Expected the monthly quota to reset on %v, got %v", want, usage.Monthly.Reset)
+	}
+}
+
+func TestQuotaUsageHandler(t *testing.T) {
+	store := NewMemoryStore()
+	quota := NewQuota(QuotaConfig{Daily: 100, Store: store, Key: KeyByHeader("X-API-Key")})
+	quota.Charge(context.Background(), "k1", 7)
+
+	// Another replica sharing the store sees the same usage.
+	replica := NewQuota(QuotaConfig{Daily: 100, Store: store, Key: KeyByHeader("X-API-Key")})
+	req := httptest.NewRequest(http.MethodGet, "/usage", nil)
+	req.Header.Set("X-API-Key", "k1")
+	recorder := httptest.NewRecorder()
+	replica.UsageHandler(nil)(recorder, req)
+
+	var usage QuotaUsage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &usage); err != nil {
+		t.Fatal(err)
+	}
+	if usage.Consumer != "k1" || usage.Daily.Used != 7 || usage.Daily.Remaining != 93 || usage.Monthly != nil {
+		t.Errorf("Unexpected usage %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	replica.UsageHandler(nil)(recorder, httptest.NewRequest(http.MethodGet, "/usage", nil))
+	checkResponse(t, recorder, http.StatusBadRequest, `{"error":"Unknown consumer"}`+"\n", "", "")
+}
@@ -0,0 +1,162 @@
+package gorouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store keeps rate limiting state, in memory or in a backend shared by
+// several replicas so they enforce one consistent limit.
+type Store interface {
+	// Update atomically replaces the state of key with the result of fn. fn
+	// receives nil if the key is missing or expired. The new state expires
+	// after ttl. If fn returns an error, the state is left unchanged.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state []byte) ([]byte, error)) error
+}
+
+// storeEntry is a state with its expiry.
+type storeEntry struct {
+	State   []byte    `json:"state"`
+	Expires time.Time `json:"expires"`
+}
+
+// MemoryStore is a Store kept in process memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]storeEntry
+	updates int
+}
+
+// NewMemoryStore creates a MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, entries: make(map[string]storeEntry)}
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+
+	// Sweep expired keys now and then so abandoned keys do not pile up.
+	if s.updates++; s.updates%1024 == 0 {
+		removeExpired(s.entries, now)
+	}
+
+	var state []byte
+	if entry, ok := s.entries[key]; ok && now.Before(entry.Expires) {
+		state = entry.State
+	}
+	state, err := fn(state)
+	if err != nil {
+		return err
+	}
+	s.entries[key] = storeEntry{State: state, Expires: now.Add(ttl)}
+	return nil
+}
+
+// Len returns the number of keys stored, including expired ones not yet removed.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// FileStore is a Store kept in a JSON file, shared by processes on the same
+// host. Updates hold a lock file next to it, so they are atomic across
+// processes. It is meant for tests and small deployments.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// NewFileStore creates a FileStore at path. The file is created on first use.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path, now: time.Now}
+}
+
+// staleLockAge is how old a lock file must be before it is considered abandoned.
+const staleLockAge = 10 * time.Second
+
+// Update implements Store.
+func (s *FileStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries := make(map[string]storeEntry)
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return err
+		}
+	}
+
+	now := s.now()
+	removeExpired(entries, now)
+	state, err := fn(entries[key].State)
+	if err != nil {
+		return err
+	}
+	entries[key] = storeEntry{State: state, Expires: now.Add(ttl)}
+
+	if data, err = json.Marshal(entries); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// lock creates the lock file, waiting while another process holds it.
+func (s *FileStore) lock(ctx context.Context) (func(), error) {
+	lockPath := s.path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o755); err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+// removeExpired deletes the entries expired at now.
+func removeExpired(entries map[string]storeEntry, now time.Time) {
+	for key, entry := range entries {
+		if !now.Before(entry.Expires) {
+			delete(entries, key)
+		}
+	}
+}
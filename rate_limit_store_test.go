@@ -0,0 +1,95 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// increment adds one to a single-byte counter state.
+func increment(state []byte) ([]byte, error) {
+	if state == nil {
+		return []byte{1}, nil
+	}
+	return []byte{state[0] + 1}, nil
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	var seen []byte
+	record := func(state []byte) ([]byte, error) {
+		seen = state
+		return increment(state)
+	}
+
+	store.Update(ctx, "k", time.Minute, increment)
+	store.Update(ctx, "k", time.Minute, record)
+	if len(seen) != 1 || seen[0] != 1 {
+		t.Errorf("Expected the stored state, got %v", seen)
+	}
+
+	now = now.Add(time.Minute)
+	store.Update(ctx, "k", time.Minute, record)
+	if seen != nil {
+		t.Errorf("Expected expired state to be dropped, got %v", seen)
+	}
+}
+
+func TestStoreUpdateError(t *testing.T) {
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   NewFileStore(t.TempDir() + "/state.json"),
+	}
+	for name, store := range stores {
+		ctx := context.Background()
+		store.Update(ctx, "k", time.Minute, increment)
+
+		fail := errors.New("fail")
+		err := store.Update(ctx, "k", time.Minute, func(state []byte) ([]byte, error) { return nil, fail })
+		if err != fail {
+			t.Errorf("%s: expected the update error, got %v", name, err)
+		}
+
+		var seen []byte
+		store.Update(ctx, "k", time.Minute, func(state []byte) ([]byte, error) {
+			seen = state
+			return state, nil
+		})
+		if len(seen) != 1 || seen[0] != 1 {
+			t.Errorf("%s: expected a failed update to keep the state, got %v", name, seen)
+		}
+	}
+}
+
+func TestFileStoreConcurrentUpdates(t *testing.T) {
+	path := t.TempDir() + "/state.json"
+	// Separate FileStores stand in for separate processes.
+	stores := []*FileStore{NewFileStore(path), NewFileStore(path)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(store *FileStore) {
+			defer wg.Done()
+			if err := store.Update(context.Background(), "k", time.Minute, increment); err != nil {
+				t.Error(err)
+			}
+		}(stores[i%2])
+	}
+	wg.Wait()
+
+	var got byte
+	stores[0].Update(context.Background(), "k", time.Minute, func(state []byte) ([]byte, error) {
+		got = state[0]
+		return state, nil
+	})
+	if got != 20 {
+		t.Errorf("Expected 20 updates, got %d", got)
+	}
+}
@@ -1,27 +1,156 @@
 package gorouter
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"golang.org/x/time/rate"
 )
 
+// RateLimitInfo describes the bucket of a rate limited request.
+type RateLimitInfo struct {
+	Limit      int           // Bucket size
+	Remaining  int           // Requests left in the bucket
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next request is allowed; zero if it is
+}
+
+// RateLimitRejectHandler writes the response for a request over its limit.
+// The rate limit headers are already set.
+type RateLimitRejectHandler func(w http.ResponseWriter, req *http.Request, info RateLimitInfo)
+
+// DefaultRateLimitRejectHandler renders a 429 error.
+func DefaultRateLimitRejectHandler(w http.ResponseWriter, req *http.Request, info RateLimitInfo) {
+	RenderError(w, req, NewHTTPError(http.StatusTooManyRequests, "Too many requests"))
+}
+
+// RateLimiterConfig represents configuration options for a RateLimiter.
+type RateLimiterConfig struct {
+	Rate           rate.Limit             // Requests per second
+	Burst          int                    // Bucket size
+	OnReject       RateLimitRejectHandler // Defaults to DefaultRateLimitRejectHandler
+	Wait           bool                   // Delay requests over the limit instead of rejecting them
+	MaxWait        time.Duration          // Longest delay in wait mode; zero waits as long as the request context allows
+	DisableHeaders bool                   // Omit the RateLimit-* and Retry-After headers
+}
+
+// RateLimiter limits all requests with a single token bucket.
 type RateLimiter struct {
 	limiter *rate.Limiter
+	policy  rateLimitPolicy
 }
 
+// NewRateLimiter creates a RateLimiter allowing r requests per second with bursts of b.
 func NewRateLimiter(r rate.Limit, b int) *RateLimiter {
+	return NewRateLimiterWithConfig(RateLimiterConfig{Rate: r, Burst: b})
+}
+
+// NewRateLimiterWithConfig creates a RateLimiter.
+func NewRateLimiterWithConfig(config RateLimiterConfig) *RateLimiter {
 	return &RateLimiter{
-		limiter: rate.NewLimiter(r, b),
+		limiter: rate.NewLimiter(config.Rate, config.Burst),
+		policy:  newRateLimitPolicy(config.OnReject, config.Wait, config.MaxWait, config.DisableHeaders),
 	}
 }
 
+// Limit is a middleware applying the rate limit.
 func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !rl.limiter.Allow() {
-			JSONError(w, "Too many requests", http.StatusTooManyRequests)
+		rl.policy.serve(w, r, rl.limiter, time.Now(), next)
+	})
+}
+
+// rateLimitPolicy decides how requests over the limit are answered.
+type rateLimitPolicy struct {
+	onReject RateLimitRejectHandler
+	wait     bool
+	maxWait  time.Duration
+	headers  bool
+}
+
+func newRateLimitPolicy(onReject RateLimitRejectHandler, wait bool, maxWait time.Duration, disableHeaders bool) rateLimitPolicy {
+	if onReject == nil {
+		onReject = DefaultRateLimitRejectHandler
+	}
+	return rateLimitPolicy{onReject: onReject, wait: wait, maxWait: maxWait, headers: !disableHeaders}
+}
+
+// serve takes a token from limiter for the request, waiting for it in wait
+// mode, and calls next or the reject handler.
+func (p rateLimitPolicy) serve(w http.ResponseWriter, req *http.Request, limiter *rate.Limiter, now time.Time, next http.Handler) {
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		// The bucket can never hold a token.
+		p.reject(w, req, RateLimitInfo{Limit: limiter.Burst()})
+		return
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 && (!p.wait || (p.maxWait > 0 && delay > p.maxWait)) {
+		reservation.CancelAt(now)
+		info := bucketInfo(limiter, now)
+		info.RetryAfter = delay
+		p.reject(w, req, info)
+		return
+	}
+
+	info := bucketInfo(limiter, now)
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			reservation.CancelAt(now)
+			info.RetryAfter = delay
+			p.reject(w, req, info)
 			return
 		}
-		next.ServeHTTP(w, r)
-	})
+	}
+	if p.headers {
+		setRateLimitHeaders(w.Header(), info)
+	}
+	next.ServeHTTP(w, req)
+}
+
+// reject sets the headers and calls the reject handler.
+func (p rateLimitPolicy) reject(w http.ResponseWriter, req *http.Request, info RateLimitInfo) {
+	if p.headers {
+		setRateLimitHeaders(w.Header(), info)
+	}
+	p.onReject(w, req, info)
+}
+
+// bucketInfo computes the state of limiter at now.
+func bucketInfo(limiter *rate.Limiter, now time.Time) RateLimitInfo {
+	burst := limiter.Burst()
+	info := RateLimitInfo{Limit: burst, Remaining: burst}
+	if limiter.Limit() == rate.Inf {
+		return info
+	}
+	tokens := limiter.TokensAt(now)
+	info.Remaining = int(math.Max(0, math.Floor(tokens)))
+	if missing := float64(burst) - tokens; missing > 0 && limiter.Limit() > 0 {
+		info.Reset = time.Duration(missing / float64(limiter.Limit()) * float64(time.Second))
+	}
+	return info
+}
+
+// setRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers of the IETF draft, and Retry-After on rejections.
+// Durations are rounded up to whole seconds.
+func setRateLimitHeaders(header http.Header, info RateLimitInfo) {
+	header.Set("RateLimit-Limit", strconv.Itoa(info.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(info.Remaining))
+	header.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(info.Reset), 10))
+	if info.RetryAfter > 0 {
+		header.Set("Retry-After", strconv.FormatInt(ceilSeconds(info.RetryAfter), 10))
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds.
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
 }
@@ -0,0 +1,87 @@
+package gorouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+func TestRateLimiterHeaders(t *testing.T) {
+	handler := NewRateLimiter(1, 2).Limit(okHandler)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	checkResponse(t, recorder, http.StatusOK, "ok", "RateLimit-Limit", "2")
+	if got := recorder.Header().Get("RateLimit-Remaining"); got != "1" {
+		t.Errorf("Expected RateLimit-Remaining 1, got %q", got)
+	}
+	if got := recorder.Header().Get("RateLimit-Reset"); got != "1" {
+		t.Errorf("Expected RateLimit-Reset 1, got %q", got)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	checkResponse(t, recorder, http.StatusTooManyRequests, `{"error":"Too many requests"}`+"\n", "Retry-After", "1")
+	if got := recorder.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("Expected RateLimit-Remaining 0, got %q", got)
+	}
+}
+
+func TestRateLimiterCustomReject(t *testing.T) {
+	var got RateLimitInfo
+	handler := NewRateLimiterWithConfig(RateLimiterConfig{
+		Rate:           0.5,
+		Burst:          1,
+		DisableHeaders: true,
+		OnReject: func(w http.ResponseWriter, req *http.Request, info RateLimitInfo) {
+			got = info
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	}).Limit(okHandler)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	checkResponse(t, recorder, http.StatusServiceUnavailable, "", "Retry-After", "")
+	if got.Limit != 1 || got.Remaining != 0 || got.RetryAfter <= time.Second {
+		t.Errorf("Unexpected rate limit info %+v", got)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	handler := NewRateLimiterWithConfig(RateLimiterConfig{Rate: 20, Burst: 1, Wait: true, MaxWait: time.Second}).Limit(okHandler)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	start := time.Now()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	checkResponse(t, recorder, http.StatusOK, "ok", "", "")
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected the request to be delayed, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterWaitLimits(t *testing.T) {
+	handler := NewRateLimiterWithConfig(RateLimiterConfig{Rate: 1, Burst: 1, Wait: true, MaxWait: 100 * time.Millisecond}).Limit(okHandler)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	checkResponse(t, recorder, http.StatusTooManyRequests, `{"error":"Too many requests"}`+"\n", "Retry-After", "1")
+
+	handler = NewRateLimiterWithConfig(RateLimiterConfig{Rate: 1, Burst: 1, Wait: true}).Limit(okHandler)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	checkResponse(t, recorder, http.StatusTooManyRequests, `{"error":"Too many requests"}`+"\n", "", "")
+}
@@ -0,0 +1,200 @@
+package gorouter
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultRecordBodyLimit is the number of bytes recorded from each body when
+// RecorderConfig leaves MaxBodyBytes unset.
+const DefaultRecordBodyLimit = 1 << 20
+
+// RecordedBody is a request or response body in a recording. Bodies that are
+// not valid UTF-8 are stored base64-encoded.
+type RecordedBody struct {
+	Data      string `json:"data,omitempty"`
+	Base64    bool   `json:"base64,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"` // Body is cut at the recording limit
+}
+
+// newRecordedBody records data, truncated reporting whether it is cut short.
+func newRecordedBody(data []byte, truncated bool) RecordedBody {
+	if utf8.Valid(data) {
+		return RecordedBody{Data: string(data), Truncated: truncated}
+	}
+	return RecordedBody{Data: base64.StdEncoding.EncodeToString(data), Base64: true, Truncated: truncated}
+}
+
+// Bytes returns the decoded body.
+func (b RecordedBody) Bytes() []byte {
+	if !b.Base64 {
+		return []byte(b.Data)
+	}
+	data, _ := base64.StdEncoding.DecodeString(b.Data)
+	return data
+}
+
+// RecordedRequest is a request in a recording.
+type RecordedRequest struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"` // Request URI, like "/users/1?full=true"
+	Host   string       `json:"host,omitempty"`
+	Header http.Header  `json:"header,omitempty"`
+	Body   RecordedBody `json:"body"`
+}
+
+// RecordedResponse is a response in a recording.
+type RecordedResponse struct {
+	Status int          `json:"status"`
+	Header http.Header  `json:"header,omitempty"`
+	Body   RecordedBody `json:"body"`
+}
+
+// Recording is one line of a traffic recording.
+type Recording struct {
+	Time     time.Time         `json:"time"`
+	Duration time.Duration     `json:"duration"`
+	Route    string            `json:"route,omitempty"`
+	Redacted bool              `json:"redacted,omitempty"` // URL, headers and bodies are masked; Replay masks responses to match
+	Request  RecordedRequest   `json:"request"`
+	Response *RecordedResponse `json:"response,omitempty"` // Set when RecorderConfig.Responses is true
+}
+
+// RecorderConfig represents configuration options for the Record middleware.
+type RecorderConfig struct {
+	Output       io.Writer                    // Where recordings are written as JSON lines, e.g. a RotatingFile; defaults to os.Stdout
+	Responses    bool                         // Record responses too, so replays can be compared with them
+	MaxBodyBytes int64                        // Bytes recorded from each body; defaults to DefaultRecordBodyLimit
+	Verbatim     bool                         // Record URLs, headers and bodies unmasked, so replays carry credentials; keep such recordings private
+	Skip         func(req *http.Request) bool // Requests not to record
+}
+
+// Record returns a middleware appending each request, and optionally its
+// response, to config.Output as a JSON line. Recordings can be fed back
+// through a handler or a live server with Replay. The URL, headers and
+// bodies are masked with the request's RedactionPolicy unless
+// config.Verbatim is set. Only the part of the request body the handler read
+// is recorded. WebSocket upgrades are not recorded.
+func Record(config RecorderConfig) Middleware {
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = DefaultRecordBodyLimit
+	}
+	mu := new(sync.Mutex)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if config.Skip != nil && config.Skip(req) || req.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			start := time.Now()
+			header := req.Header.Clone()
+			body := &captureReader{ReadCloser: req.Body, captureBuffer: captureBuffer{limit: config.MaxBodyBytes}}
+			if req.Body != nil && req.Body != http.NoBody {
+				req.Body = body
+			}
+			rw := WrapResponseWriter(w)
+			var response http.ResponseWriter = rw
+			cw := &captureWriter{ResponseWriter: rw, captureBuffer: captureBuffer{limit: config.MaxBodyBytes}}
+			if config.Responses {
+				response = cw
+			}
+			next.ServeHTTP(response, req)
+
+			recording := Recording{
+				Time:     start,
+				Duration: time.Since(start),
+				Route:    RoutePattern(req),
+				Request: RecordedRequest{
+					Method: req.Method,
+					URL:    req.URL.RequestURI(),
+					Host:   req.Host,
+					Header: header,
+					Body:   config.body(req, header.Get("Content-Type"), &body.captureBuffer),
+				},
+			}
+			if config.Responses {
+				status := rw.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				recording.Response = &RecordedResponse{
+					Status: status,
+					Header: rw.Header().Clone(),
+					Body:   config.body(req, rw.Header().Get("Content-Type"), &cw.captureBuffer),
+				}
+			}
+			if !config.Verbatim {
+				recording.Redacted = true
+				redaction := RedactionFrom(req.Context())
+				recording.Request.URL = redaction.URL(recording.Request.URL)
+				recording.Request.Header = redaction.Header(recording.Request.Header)
+				if recording.Response != nil {
+					recording.Response.Header = redaction.Header(recording.Response.Header)
+				}
+			}
+
+			line, err := json.Marshal(recording)
+			if err != nil {
+				logRequestError(req, fmt.Errorf("recording request: %w", err), slog.LevelError)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if _, err := config.Output.Write(append(line, '\n')); err != nil {
+				logRequestError(req, fmt.Errorf("recording request: %w", err), slog.LevelError)
+			}
+		})
+	}
+}
+
+// body records a captured body, redacting textual bodies unless recording verbatim.
+func (config *RecorderConfig) body(req *http.Request, contentType string, c *captureBuffer) RecordedBody {
+	data := c.buf.Bytes()
+	if !config.Verbatim {
+		data = redactRecordedBody(RedactionFrom(req.Context()), contentType, data)
+	}
+	return newRecordedBody(data, c.size > int64(c.buf.Len()))
+}
+
+// redactRecordedBody masks a textual body of contentType with redaction.
+func redactRecordedBody(redaction *RedactionPolicy, contentType string, data []byte) []byte {
+	if !utf8.Valid(data) {
+		return data
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return []byte(redactBody(redaction, mediaType, data))
+}
+
+// ReadRecordings reads the JSON lines written by Record. Blank lines are
+// skipped.
+func ReadRecordings(r io.Reader) ([]Recording, error) {
+	var recordings []Recording
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var recording Recording
+		if err := json.Unmarshal(scanner.Bytes(), &recording); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		recordings = append(recordings, recording)
+	}
+	return recordings, scanner.Err()
+}
@@ -0,0 +1,159 @@
+package gorouter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newRecordedRouter(output io.Writer, greeting string) *Router {
+	router := NewRouter()
+	if output != nil {
+		router.Use(Record(RecorderConfig{Output: output, Responses: true}))
+	}
+	router.AddRoute(http.MethodPost, "/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(body)
+	})
+	router.AddRoute(http.MethodGet, "/greet", func(w http.ResponseWriter, r *http.Request) {
+		JSONResponse(w, map[string]string{"greeting": greeting, "name": r.URL.Query().Get("name")}, http.StatusOK)
+	})
+	return router
+}
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	router := newRecordedRouter(&buf, "hello")
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("\xff\x00binary"))
+	req.Header.Set("X-Test", "1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/greet?name=ann", nil))
+
+	recordings, err := ReadRecordings(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recordings) != 2 {
+		t.Fatalf("Expected 2 recordings, got %d: %s", len(recordings), buf.String())
+	}
+	echo := recordings[0]
+	if echo.Route != "/echo" || echo.Request.Header.Get("X-Test") != "1" || !echo.Request.Body.Base64 || string(echo.Request.Body.Bytes()) != "\xff\x00binary" {
+		t.Errorf("Unexpected recorded request %+v", echo.Request)
+	}
+	if echo.Response == nil || echo.Response.Status != http.StatusOK || string(echo.Response.Body.Bytes()) != "\xff\x00binary" {
+		t.Errorf("Unexpected recorded response %+v", echo.Response)
+	}
+	if greet := recordings[1]; greet.Request.URL != "/greet?name=ann" || greet.Request.Body.Data != "" {
+		t.Errorf("Unexpected recorded request %+v", greet.Request)
+	}
+}
+
+func TestRecordRedactsAndTruncates(t *testing.T) {
+	var buf bytes.Buffer
+	handler := Record(RecorderConfig{Output: &buf, MaxBodyBytes: 30})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/login?token=t", strings.NewReader(`{"user":"ann","password":"hunter2","remember":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	recordings, err := ReadRecordings(&buf)
+	if err != nil || len(recordings) != 1 {
+		t.Fatalf("Expected 1 recording, got %d (%v)", len(recordings), err)
+	}
+	recorded := recordings[0]
+	if recorded.Request.URL != "/login?token=[REDACTED]" || recorded.Request.Header.Get("Authorization") != RedactedValue {
+		t.Errorf("Expected the URL and headers to be redacted, got %+v", recorded.Request)
+	}
+	if body := recorded.Request.Body; body.Data != `{"user":"ann","password":"[REDACTED]"` || !body.Truncated {
+		t.Errorf("Expected the body to be truncated and redacted, got %+v", body)
+	}
+	if recorded.Response != nil {
+		t.Errorf("Expected no response to be recorded, got %+v", recorded.Response)
+	}
+
+	// A truncated request is not replayed.
+	results := Replay(context.Background(), recordings, ReplayConfig{Handler: okHandler})
+	if len(results) != 1 || !errors.Is(results[0].Err, ErrTruncatedRecording) || results[0].Response != nil {
+		t.Errorf("Expected the truncated recording to be reported, got %+v", results)
+	}
+}
+
+func TestRecordVerbatim(t *testing.T) {
+	var buf bytes.Buffer
+	handler := Record(RecorderConfig{Output: &buf, Verbatim: true})(okHandler)
+	req := httptest.NewRequest(http.MethodGet, "/?token=t", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	recordings, _ := ReadRecordings(&buf)
+	if len(recordings) != 1 || recordings[0].Request.URL != "/?token=t" || recordings[0].Request.Header.Get("Authorization") != "Bearer abc" {
+		t.Errorf("Expected the request to be recorded verbatim, got %+v", recordings)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	router := newRecordedRouter(&buf, "hello")
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("ping")))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/greet?name=ann", nil))
+	recordings, err := ReadRecordings(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaying through an unchanged router passes.
+	for _, result := range Replay(context.Background(), recordings, ReplayConfig{Handler: newRecordedRouter(nil, "hello")}) {
+		if !result.Passed() {
+			t.Errorf("Expected %s to pass, got %v %v", result.Recording.Request.URL, result.Err, result.Mismatches)
+		}
+	}
+
+	// A changed response is reported, against a live server too.
+	server := httptest.NewServer(newRecordedRouter(nil, "hi"))
+	defer server.Close()
+	results := Replay(context.Background(), recordings, ReplayConfig{BaseURL: server.URL})
+	if len(results) != 2 || !results[0].Passed() || results[1].Passed() {
+		t.Fatalf("Expected only the second replay to fail, got %+v", results)
+	}
+	if mismatches := results[1].Mismatches; len(mismatches) != 1 || !strings.HasPrefix(mismatches[0], "body:") {
+		t.Errorf("Expected a body mismatch, got %v", mismatches)
+	}
+}
+
+func TestReplayRedactedResponse(t *testing.T) {
+	var buf bytes.Buffer
+	login := func(w http.ResponseWriter, r *http.Request) {
+		JSONResponse(w, map[string]string{"token": "abc", "user": "x"}, http.StatusOK)
+	}
+	router := NewRouter()
+	router.Use(Record(RecorderConfig{Output: &buf, Responses: true}))
+	router.AddRoute(http.MethodGet, "/login", login)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	recordings, err := ReadRecordings(&buf)
+	if err != nil || len(recordings) != 1 {
+		t.Fatalf("Expected 1 recording, got %d (%v)", len(recordings), err)
+	}
+	if body := recordings[0].Response.Body.Data; !recordings[0].Redacted || !strings.Contains(body, RedactedValue) {
+		t.Fatalf("Expected the recorded response to be redacted, got %s", body)
+	}
+
+	for _, result := range Replay(context.Background(), recordings, ReplayConfig{Handler: router}) {
+		if !result.Passed() {
+			t.Errorf("Expected the redacted recording to pass, got %v %v", result.Err, result.Mismatches)
+		}
+		if !strings.Contains(result.Response.Body.Data, "abc") {
+			t.Errorf("Expected the replayed response to be returned unmasked, got %s", result.Response.Body.Data)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package gorouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// PanicReport describes a panic recovered while serving a request.
+type PanicReport struct {
+	Value        interface{} `json:"-"`
+	Message      string      `json:"message"`
+	Stack        string      `json:"stack,omitempty"`
+	Time         time.Time   `json:"time"`
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	RoutePattern string      `json:"route,omitempty"`
+	RemoteAddr   string      `json:"remote"`
+	UserAgent    string      `json:"user_agent,omitempty"`
+	Headers      http.Header `json:"headers,omitempty"`
+
+	ctx context.Context // of the request, for request-scoped logging
+}
+
+// PanicReporter receives reports of recovered panics.
+type PanicReporter interface {
+	ReportPanic(report *PanicReport)
+}
+
+// PanicReporterFunc adapts a function to the PanicReporter interface.
+type PanicReporterFunc func(report *PanicReport)
+
+// ReportPanic calls f(report).
+func (f PanicReporterFunc) ReportPanic(report *PanicReport) {
+	f(report)
+}
+
+// PanicError is the error rendered for a recovered panic.
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// RecovererConfig represents configuration options for the Recoverer middleware.
+type RecovererConfig struct {
+	Reporters      []PanicReporter // Reporters to notify; defaults to LogPanicReporter
+	DisableStack   bool            // Do not capture the stack trace
+	IncludeHeaders bool            // Include request headers in reports
+}
+
+// Recoverer returns a middleware that recovers from panics, reports them and
+// renders a 500 response through the router's error renderer. Nothing is
+// written if the response was already started, and http.ErrAbortHandler is
+// re-panicked so net/http can abort the connection.
+func Recoverer(config RecovererConfig) Middleware {
+	reporters := config.Reporters
+	if len(reporters) == 0 {
+		reporters = []PanicReporter{LogPanicReporter()}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rw := WrapResponseWriter(w)
+			defer func() {
+				value := recover()
+				if value == nil {
+					return
+				}
+				if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(value)
+				}
+
+				report := newPanicReport(req, value, config)
+				for _, reporter := range reporters {
+					reporter.ReportPanic(report)
+				}
+				if rw.Written() {
+					return
+				}
+				panicErr := &PanicError{Value: value, Stack: report.Stack}
+				RenderError(rw, req, NewHTTPError(http.StatusInternalServerError, "").Wrap(panicErr))
+			}()
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// newPanicReport collects the details of a recovered panic.
+func newPanicReport(req *http.Request, value interface{}, config RecovererConfig) *PanicReport {
+	redaction := RedactionFrom(req.Context())
+	report := &PanicReport{
+		ctx:          req.Context(),
+		Value:        value,
+		Message:      redaction.String(fmt.Sprint(value)),
+		Time:         time.Now(),
+		Method:       req.Method,
+		URL:          redaction.URL(req.URL.String()),
+		RoutePattern: RoutePattern(req),
+		RemoteAddr:   req.RemoteAddr,
+		UserAgent:    req.UserAgent(),
+	}
+	if !config.DisableStack {
+		report.Stack = string(debug.Stack())
+	}
+	if config.IncludeHeaders {
+		report.Headers = redaction.Header(req.Header)
+	}
+	return report
+}
+
+// LogPanicReporter returns a PanicReporter that logs panics with the
+// request-scoped logger.
+func LogPanicReporter() PanicReporter {
+	return PanicReporterFunc(func(report *PanicReport) {
+		LoggerFrom(report.ctx).Log(report.ctx, slog.LevelError, "panic: "+report.Message,
+			"method", report.Method,
+			"url", report.URL,
+			"remote", report.RemoteAddr,
+			"stack", report.Stack,
+		)
+	})
+}
+
+// FilePanicReporter appends panic reports to a file as JSON lines.
+type FilePanicReporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFilePanicReporter opens (or creates) the file at path for appending reports.
+func NewFilePanicReporter(path string) (*FilePanicReporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FilePanicReporter{file: file}, nil
+}
+
+// ReportPanic appends the report to the file.
+func (fr *FilePanicReporter) ReportPanic(report *PanicReport) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		defaultLogger.Log(context.Background(), slog.LevelError, "Failed to encode panic report", "error", err)
+		return
+	}
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if _, err := fr.file.Write(append(data, '\n')); err != nil {
+		defaultLogger.Log(context.Background(), slog.LevelError, "Failed to write panic report", "error", err)
+	}
+}
+
+// Close closes the underlying file.
+func (fr *FilePanicReporter) Close() error {
+	return fr.file.Close()
+}
+
+// MemoryPanicReporter keeps panic reports in memory, mainly for tests.
+type MemoryPanicReporter struct {
+	mu      sync.Mutex
+	reports []*PanicReport
+}
+
+// ReportPanic stores the report.
+func (mr *MemoryPanicReporter) ReportPanic(report *PanicReport) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.reports = append(mr.reports, report)
+}
+
+// Reports returns the stored reports.
+func (mr *MemoryPanicReporter) Reports() []*PanicReport {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	return append([]*PanicReport(nil), mr.reports...)
+}
+
+// Reset removes all stored reports.
+func (mr *MemoryPanicReporter) Reset() {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.reports = nil
+}
@@ -0,0 +1,104 @@
+package gorouter
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecovererReportsPanic(t *testing.T) {
+	reporter := &MemoryPanicReporter{}
+	r := NewRouter()
+	r.Use(Recoverer(RecovererConfig{Reporters: []PanicReporter{reporter}, IncludeHeaders: true}))
+	r.AddRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Trace", "abc")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	checkResponse(t, recorder, http.StatusInternalServerError, `{"error":"Internal Server Error"}`+"\n", "", "")
+
+	reports := reporter.Reports()
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(reports))
+	}
+	report := reports[0]
+	if report.Message != "boom" || report.RoutePattern != "/users/:id" || report.Headers.Get("X-Trace") != "abc" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if !strings.Contains(report.Stack, "recoverer_test.go") {
+		t.Errorf("Expected stack trace to include the panicking handler, got %s", report.Stack)
+	}
+}
+
+func TestRecovererAfterResponseStarted(t *testing.T) {
+	reporter := &MemoryPanicReporter{}
+	handler := Recoverer(RecovererConfig{Reporters: []PanicReporter{reporter}})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		panic("late")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	checkResponse(t, recorder, http.StatusOK, "partial", "", "")
+	if len(reporter.Reports()) != 1 {
+		t.Errorf("Expected panic to be reported once, got %d", len(reporter.Reports()))
+	}
+}
+
+func TestRecovererRepanicsAbortHandler(t *testing.T) {
+	reporter := &MemoryPanicReporter{}
+	handler := Recoverer(RecovererConfig{Reporters: []PanicReporter{reporter}})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("Expected http.ErrAbortHandler to be re-panicked")
+		}
+		if len(reporter.Reports()) != 0 {
+			t.Error("Expected http.ErrAbortHandler not to be reported")
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestFilePanicReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.jsonl")
+	reporter, err := NewFilePanicReporter(path)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	reporter.ReportPanic(&PanicReport{Message: "first", Method: http.MethodGet, URL: "/"})
+	reporter.ReportPanic(&PanicReport{Message: "second", Method: http.MethodGet, URL: "/"})
+	reporter.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open report file: %v", err)
+	}
+	defer file.Close()
+
+	var messages []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var report PanicReport
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		messages = append(messages, report.Message)
+	}
+	if strings.Join(messages, ",") != "first,second" {
+		t.Errorf("Expected reports first,second, got %v", messages)
+	}
+}
@@ -0,0 +1,245 @@
+package gorouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces redacted data when a RedactionPolicy leaves
+// Replacement unset.
+const RedactedValue = "[REDACTED]"
+
+// RedactionPolicy describes the sensitive data masked before requests are
+// logged or reported: by access logs, error logs, panic reports and body
+// dumps. Set it with Router.SetRedactionPolicy; DefaultRedactionPolicy
+// applies otherwise. A policy must not be modified once in use.
+type RedactionPolicy struct {
+	Headers     []string         // Header names whose values are masked, case-insensitively
+	QueryKeys   []string         // Query parameter names whose values are masked, case-insensitively
+	JSONPaths   []string         // JSON body fields masked, case-insensitively: "password" at any depth, or dotted paths from the root like "card.number" or "items.*.token"
+	Patterns    []*regexp.Regexp // Matches masked in any logged text and JSON string values
+	CardNumbers bool             // Mask digit sequences that pass the Luhn check, like credit card numbers
+	Replacement string           // Defaults to RedactedValue
+
+	compileOnce sync.Once
+	fieldValues []*regexp.Regexp // Match the values of JSONPaths fields in invalid JSON
+}
+
+// DefaultRedactionPolicy masks credentials in common headers, query
+// parameters and JSON fields, and credit card numbers.
+var DefaultRedactionPolicy = &RedactionPolicy{
+	Headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"},
+	QueryKeys:   []string{"token", "access_token", "refresh_token", "api_key", "apikey", "password", "secret"},
+	JSONPaths:   []string{"password", "secret", "token", "access_token", "refresh_token", "api_key"},
+	CardNumbers: true,
+}
+
+// cardNumberPattern matches candidate card numbers, optionally grouped by
+// spaces or dashes; candidates are confirmed with the Luhn check.
+var cardNumberPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// SetRedactionPolicy sets the policy applied to what the router's requests
+// log and report. An empty policy disables redaction.
+func (r *Router) SetRedactionPolicy(policy *RedactionPolicy) {
+	r.redaction = policy
+}
+
+// RedactionFrom returns the redaction policy of the Router serving ctx, or
+// DefaultRedactionPolicy.
+func RedactionFrom(ctx context.Context) *RedactionPolicy {
+	if r := routerFromContext(ctx); r != nil && r.redaction != nil {
+		return r.redaction
+	}
+	return DefaultRedactionPolicy
+}
+
+func (p *RedactionPolicy) replacement() string {
+	if p.Replacement == "" {
+		return RedactedValue
+	}
+	return p.Replacement
+}
+
+// Header returns a copy of header with the values of sensitive headers masked.
+func (p *RedactionPolicy) Header(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		masked := make([]string, len(values))
+		for i, value := range values {
+			if containsFold(p.Headers, name) {
+				masked[i] = p.replacement()
+			} else {
+				masked[i] = p.String(value)
+			}
+		}
+		redacted[name] = masked
+	}
+	return redacted
+}
+
+// URL masks sensitive query parameter values in a URL or request URI,
+// keeping the order of the parameters.
+func (p *RedactionPolicy) URL(uri string) string {
+	base, query, found := strings.Cut(uri, "?")
+	if !found {
+		return p.String(uri)
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, hasValue := strings.Cut(param, "=")
+		if !hasValue {
+			continue
+		}
+		if name, err := url.QueryUnescape(key); err == nil && containsFold(p.QueryKeys, name) {
+			params[i] = key + "=" + p.replacement()
+		}
+	}
+	return p.String(base + "?" + strings.Join(params, "&"))
+}
+
+// String masks the configured patterns and card numbers in s.
+func (p *RedactionPolicy) String(s string) string {
+	for _, pattern := range p.Patterns {
+		s = pattern.ReplaceAllLiteralString(s, p.replacement())
+	}
+	if p.CardNumbers {
+		s = cardNumberPattern.ReplaceAllStringFunc(s, func(match string) string {
+			if luhnValid(match) {
+				return p.replacement()
+			}
+			return match
+		})
+	}
+	return s
+}
+
+// JSON masks the configured fields and patterns in a JSON document. Bodies
+// that are not valid JSON, such as truncated ones, are masked as text, with
+// the values of fields named by the last segment of a JSON path masked too.
+func (p *RedactionPolicy) JSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err == nil && !decoder.More() {
+		if redacted, err := json.Marshal(p.redactJSON(doc, nil)); err == nil {
+			return redacted
+		}
+	}
+
+	text := string(body)
+	replacement := "${1}" + strings.ReplaceAll(strconv.Quote(p.replacement()), "$", "$$")
+	for _, value := range p.fieldValuePatterns() {
+		text = value.ReplaceAllString(text, replacement)
+	}
+	return []byte(p.String(text))
+}
+
+// fieldValuePatterns returns, compiled once per policy, patterns matching
+// the values of the fields named by the last segment of each JSON path.
+func (p *RedactionPolicy) fieldValuePatterns() []*regexp.Regexp {
+	p.compileOnce.Do(func() {
+		for _, path := range p.JSONPaths {
+			field := path[strings.LastIndex(path, ".")+1:]
+			if field == "*" {
+				continue
+			}
+			// A string value, possibly cut off, or any other scalar.
+			p.fieldValues = append(p.fieldValues, regexp.MustCompile(`(?i)("`+regexp.QuoteMeta(field)+`"\s*:\s*)(?:"(?:[^"\\]|\\.)*"?|[^\s,}\]]+)`))
+		}
+	})
+	return p.fieldValues
+}
+
+// redactJSON masks v, found at path in the document.
+func (p *RedactionPolicy) redactJSON(v interface{}, path []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := append(path[:len(path):len(path)], key)
+			if p.matchesJSONPath(childPath) {
+				v[key] = p.replacement()
+			} else {
+				v[key] = p.redactJSON(child, childPath)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			childPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+			if p.matchesJSONPath(childPath) {
+				v[i] = p.replacement()
+			} else {
+				v[i] = p.redactJSON(child, childPath)
+			}
+		}
+	case string:
+		return p.String(v)
+	case json.Number:
+		if s := p.String(v.String()); s != v.String() {
+			return s
+		}
+	}
+	return v
+}
+
+// matchesJSONPath reports whether a configured JSON path selects path.
+func (p *RedactionPolicy) matchesJSONPath(path []string) bool {
+	for _, pattern := range p.JSONPaths {
+		if !strings.Contains(pattern, ".") {
+			if strings.EqualFold(pattern, path[len(path)-1]) {
+				return true
+			}
+			continue
+		}
+		segments := strings.Split(pattern, ".")
+		if len(segments) != len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range segments {
+			if segment != "*" && !strings.EqualFold(segment, path[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
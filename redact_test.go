@@ -0,0 +1,105 @@
+package gorouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactionPolicyURL(t *testing.T) {
+	policy := DefaultRedactionPolicy
+	got := policy.URL("/search?q=shoes&access_token=abc123&Api_Key=k&flag")
+	if want := "/search?q=shoes&access_token=[REDACTED]&Api_Key=[REDACTED]&flag"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := policy.URL("/pay?card=4111-1111-1111-1111&order=1234567890123"); got != "/pay?card=[REDACTED]&order=1234567890123" {
+		t.Errorf("Expected only Luhn-valid numbers to be masked, got %q", got)
+	}
+}
+
+func TestRedactionPolicyHeader(t *testing.T) {
+	policy := &RedactionPolicy{Headers: []string{"authorization"}, Patterns: []*regexp.Regexp{regexp.MustCompile(`sess-\w+`)}, Replacement: "***"}
+	header := http.Header{"Authorization": {"Bearer t"}, "X-Trace": {"id sess-42 end"}}
+
+	redacted := policy.Header(header)
+	if redacted.Get("Authorization") != "***" || redacted.Get("X-Trace") != "id *** end" {
+		t.Errorf("Unexpected redacted headers %v", redacted)
+	}
+	if header.Get("Authorization") != "Bearer t" {
+		t.Error("Expected the original header to be left unchanged")
+	}
+}
+
+func TestRedactionPolicyJSON(t *testing.T) {
+	policy := &RedactionPolicy{JSONPaths: []string{"password", "card.number", "items.*.token"}, CardNumbers: true}
+	body := `{"user":{"name":"ann","password":"p"},"card":{"number":"x","cvc":"1"},"items":[{"token":"t","id":7}],"note":"pay 4111 1111 1111 1111","n":4111111111111111}`
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(policy.JSON([]byte(body)), &got); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]interface{}
+	json.Unmarshal([]byte(`{"user":{"name":"ann","password":"[REDACTED]"},"card":{"number":"[REDACTED]","cvc":"1"},"items":[{"token":"[REDACTED]","id":7}],"note":"pay [REDACTED]","n":"[REDACTED]"}`), &want)
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("Expected %s, got %s", wantJSON, gotJSON)
+	}
+
+	if got := string(policy.JSON([]byte("password=p card=4111111111111111"))); got != "password=p card=[REDACTED]" {
+		t.Errorf("Expected invalid JSON to be masked as text, got %q", got)
+	}
+	if got := string(policy.JSON([]byte(`{"user":{"password":"hun`))); got != `{"user":{"password":"[REDACTED]"` {
+		t.Errorf("Expected fields of truncated JSON to be masked, got %q", got)
+	}
+	if got := string(DefaultRedactionPolicy.JSON([]byte(`{"Password":"p","Card":{"Number":"x"}}`))); strings.Contains(got, `"p"`) {
+		t.Errorf("Expected JSON fields to be matched case-insensitively, got %s", got)
+	}
+	if got := string(DefaultRedactionPolicy.JSON([]byte(`{"PASSWORD":"hun`))); got != `{"PASSWORD":"[REDACTED]"` {
+		t.Errorf("Expected fields of truncated JSON to be matched case-insensitively, got %q", got)
+	}
+}
+
+func TestRedactionAppliedToErrorLogs(t *testing.T) {
+	logger, buf := newBufferLogger()
+	router := NewRouter()
+	router.SetLogger(logger)
+	router.AddRoute(http.MethodGet, "/pay", Adapt(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("charging card 4111 1111 1111 1111 failed")
+	}))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pay", nil))
+	if strings.Contains(buf.String(), "4111") || !strings.Contains(buf.String(), "charging card [REDACTED] failed") {
+		t.Errorf("Expected the logged error to be redacted, got %s", buf.String())
+	}
+}
+
+func TestRedactionAppliedToLogs(t *testing.T) {
+	var buf bytes.Buffer
+	var report *PanicReport
+	router := NewRouter()
+	router.SetRedactionPolicy(&RedactionPolicy{Headers: []string{"X-Secret"}, QueryKeys: []string{"sig"}})
+	router.Use(AccessLog(AccessLogConfig{Output: &buf, Format: AccessLogLogfmt, Fields: []string{LogFieldPath}}))
+	router.AddRoute(http.MethodGet, "/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("failed")
+	}, Recoverer(RecovererConfig{
+		IncludeHeaders: true,
+		Reporters:      []PanicReporter{PanicReporterFunc(func(r *PanicReport) { report = r })},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/boom?sig=s3cret&page=2", nil)
+	req.Header.Set("X-Secret", "hunter2")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(buf.String(), "s3cret") || !strings.Contains(buf.String(), "sig=[REDACTED]&page=2") {
+		t.Errorf("Expected the access log to be redacted, got %q", buf.String())
+	}
+	if report == nil || report.Headers.Get("X-Secret") != RedactedValue || strings.Contains(report.URL, "s3cret") {
+		t.Errorf("Expected the panic report to be redacted, got %+v", report)
+	}
+}
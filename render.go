@@ -0,0 +1,87 @@
+package gorouter
+
+import (
+	"net/http"
+	"strings"
+)
+
+// mediaEncoder is an Encoder registered for a media type.
+type mediaEncoder struct {
+	mediaType string
+	encoder   Encoder
+}
+
+// defaultEncoders are used by Render when the Router has no custom encoders.
+var defaultEncoders = []mediaEncoder{
+	{"application/json", JSONEncoder},
+	{"application/xml", XMLEncoder},
+	{"application/yaml", YAMLEncoder},
+	{"application/msgpack", MessagePackEncoder},
+	{"application/cbor", CBOREncoder},
+	{"text/csv", CSVEncoder},
+	{"text/plain", TextEncoder},
+	{"application/x-yaml", YAMLEncoder},
+	{"application/x-msgpack", MessagePackEncoder},
+}
+
+// RegisterEncoder registers an encoder used by Render for the media type,
+// replacing any encoder already registered for it. New media types are
+// preferred after the existing ones when the client has no preference.
+func (r *Router) RegisterEncoder(mediaType string, encoder Encoder) {
+	if r.encoders == nil {
+		r.encoders = append([]mediaEncoder(nil), defaultEncoders...)
+	}
+	for i, me := range r.encoders {
+		if me.mediaType == mediaType {
+			r.encoders[i].encoder = encoder
+			return
+		}
+	}
+	r.encoders = append(r.encoders, mediaEncoder{mediaType: mediaType, encoder: encoder})
+}
+
+// Render encodes data in the media type preferred by the request's Accept
+// header and sends it with the given status code. If no registered encoder is
+// acceptable, a 406 error is rendered instead.
+func Render(w http.ResponseWriter, req *http.Request, data interface{}, statusCode int) {
+	encoders := defaultEncoders
+	if r := routerFromContext(req.Context()); r != nil && r.encoders != nil {
+		encoders = r.encoders
+	}
+
+	offers := make([]string, len(encoders))
+	for i, me := range encoders {
+		offers[i] = me.mediaType
+	}
+	w.Header().Add("Vary", "Accept")
+
+	i := negotiate(strings.Join(req.Header.Values("Accept"), ","), offers)
+	if i < 0 {
+		RenderError(w, req, NewHTTPError(http.StatusNotAcceptable, "").WithDetails(map[string]interface{}{"available": offers}))
+		return
+	}
+
+	// Encode into a buffer first so encoding failures can still be reported.
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if data != nil {
+		encoder := encoders[i].encoder
+		if _, ok := encoder.(jsonEncoder); ok {
+			encoder = EncoderFunc(jsonConfigFrom(req.Context()).encode)
+		}
+		if err := encoder.Encode(buf, data); err != nil {
+			RenderError(w, req, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentTypeFor(offers[i]))
+	writeBody(w, buf.Bytes(), statusCode)
+}
+
+// contentTypeFor adds a UTF-8 charset to textual media types.
+func contentTypeFor(mediaType string) string {
+	if strings.HasPrefix(mediaType, "text/") {
+		return mediaType + "; charset=utf-8"
+	}
+	return mediaType
+}
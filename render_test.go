@@ -0,0 +1,109 @@
+package gorouter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type renderItem struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/plain"}
+	tests := []struct {
+		accept   string
+		expected int
+	}{
+		{"", 0},
+		{"*/*", 0},
+		{"application/xml", 1},
+		{"text/*", 2},
+		{"application/xml;q=0.5, text/plain", 2},
+		{"application/xml, application/json", 1},
+		{"application/*;q=0.2, application/json;q=0", 1},
+		{"image/png", -1},
+		{"*/*;q=0", -1},
+	}
+	for _, tt := range tests {
+		if got := negotiate(tt.accept, offers); got != tt.expected {
+			t.Errorf("negotiate(%q) = %d, expected %d", tt.accept, got, tt.expected)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := NewRouter()
+	r.AddRoute(http.MethodGet, "/items", Adapt(func(c *Context) {
+		c.Render([]renderItem{{1, "pen"}, {2, "ink"}}, http.StatusOK)
+	}))
+
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"application/json", http.StatusOK, "application/json", `[{"id":1,"name":"pen"},{"id":2,"name":"ink"}]` + "\n"},
+		{"text/csv", http.StatusOK, "text/csv; charset=utf-8", "id,name\n1,pen\n2,ink\n"},
+		{"application/yaml", http.StatusOK, "application/yaml", "- id: 1\n  name: pen\n- id: 2\n  name: ink\n"},
+		{"application/xml", http.StatusOK, "application/xml", "<items><renderItem><id>1</id><name>pen</name></renderItem><renderItem><id>2</id><name>ink</name></renderItem></items>"},
+		{"image/png", http.StatusNotAcceptable, "application/json", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Accept", tt.accept)
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+
+		if recorder.Code != tt.status {
+			t.Errorf("Accept %s: expected status %d, got %d", tt.accept, tt.status, recorder.Code)
+		}
+		if ct := recorder.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("Accept %s: expected Content-Type %s, got %s", tt.accept, tt.contentType, ct)
+		}
+		if tt.body != "" && recorder.Body.String() != tt.body {
+			t.Errorf("Accept %s: expected body %q, got %q", tt.accept, tt.body, recorder.Body.String())
+		}
+	}
+}
+
+func TestRenderBinaryFormats(t *testing.T) {
+	for _, mediaType := range []string{"application/msgpack", "application/cbor"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", mediaType)
+		recorder := httptest.NewRecorder()
+		Render(recorder, req, renderItem{1, "pen"}, http.StatusOK)
+
+		if recorder.Header().Get("Content-Type") != mediaType || recorder.Body.Len() == 0 {
+			t.Errorf("Expected non-empty %s body, got %q with %s", mediaType, recorder.Body.Bytes(), recorder.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	r := NewRouter()
+	r.RegisterEncoder("text/plain", EncoderFunc(func(w io.Writer, data interface{}) error {
+		_, err := io.WriteString(w, strings.ToUpper(data.(string)))
+		return err
+	}))
+	r.RegisterEncoder("application/vnd.shout", TextEncoder)
+	r.AddRoute(http.MethodGet, "/", func(w http.ResponseWriter, req *http.Request) {
+		Render(w, req, "hello", http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/plain")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	checkResponse(t, recorder, http.StatusOK, "HELLO", "Content-Type", "text/plain; charset=utf-8")
+
+	req.Header.Set("Accept", "application/vnd.shout")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	checkResponse(t, recorder, http.StatusOK, "hello", "Content-Type", "application/vnd.shout")
+}
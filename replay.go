@@ -0,0 +1,192 @@
+package gorouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ErrTruncatedRecording is the error of a ReplayResult whose request body
+// was cut at the recording limit, so it cannot be replayed faithfully.
+var ErrTruncatedRecording = errors.New("gorouter: recorded request body is truncated")
+
+// ReplayConfig represents configuration options for Replay.
+type ReplayConfig struct {
+	Handler   http.Handler                                        // Handler recordings are replayed through, like a Router
+	BaseURL   string                                              // Address of a live server, like "http://localhost:8080", used when Handler is nil
+	Client    *http.Client                                        // Client for BaseURL; defaults to http.DefaultClient
+	Headers   []string                                            // Response headers compared; defaults to Content-Type
+	Redaction *RedactionPolicy                                    // Masks responses compared with redacted recordings; defaults to the policy of Handler if it is a Router, or DefaultRedactionPolicy
+	Compare   func(recorded, replayed *RecordedResponse) []string // Replaces the default comparison of status, headers and body
+}
+
+// ReplayResult is the outcome of replaying a recording.
+type ReplayResult struct {
+	Recording  Recording
+	Response   *RecordedResponse // Replayed response, nil if Err is set
+	Mismatches []string          // Differences from the recorded response
+	Err        error             // Error sending the request
+}
+
+// Passed reports whether the request was replayed and its response matched
+// the recorded one. Recordings without a response only need to be sent.
+func (r *ReplayResult) Passed() bool {
+	return r.Err == nil && len(r.Mismatches) == 0
+}
+
+// Replay sends recorded requests in order through config.Handler, or to the
+// server at config.BaseURL, and compares each response with the recorded
+// one. Responses to redacted recordings are masked before the comparison.
+// Requests with a truncated body are not sent; their result has
+// ErrTruncatedRecording. It stops early if ctx is canceled.
+func Replay(ctx context.Context, recordings []Recording, config ReplayConfig) []ReplayResult {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Headers == nil {
+		config.Headers = []string{"Content-Type"}
+	}
+	if config.Compare == nil {
+		config.Compare = config.compare
+	}
+	if config.Redaction == nil {
+		config.Redaction = DefaultRedactionPolicy
+		if router, ok := config.Handler.(*Router); ok && router.redaction != nil {
+			config.Redaction = router.redaction
+		}
+	}
+
+	results := make([]ReplayResult, 0, len(recordings))
+	for _, recording := range recordings {
+		if ctx.Err() != nil {
+			break
+		}
+		result := ReplayResult{Recording: recording}
+		if recording.Request.Body.Truncated {
+			result.Err = ErrTruncatedRecording
+			results = append(results, result)
+			continue
+		}
+		result.Response, result.Err = config.send(ctx, &recording.Request)
+		if result.Err == nil && recording.Response != nil {
+			replayed := result.Response
+			if recording.Redacted {
+				replayed = config.redact(replayed)
+			}
+			result.Mismatches = config.Compare(recording.Response, replayed)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// ReplayFile replays the recordings in a file written by Record.
+func ReplayFile(ctx context.Context, filename string, config ReplayConfig) ([]ReplayResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	recordings, err := ReadRecordings(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	return Replay(ctx, recordings, config), nil
+}
+
+// send replays a recorded request and records the response.
+func (config *ReplayConfig) send(ctx context.Context, recorded *RecordedRequest) (*RecordedResponse, error) {
+	target := recorded.URL
+	if config.Handler == nil {
+		target = strings.TrimSuffix(config.BaseURL, "/") + recorded.URL
+	}
+	req, err := http.NewRequestWithContext(ctx, recorded.Method, target, bytes.NewReader(recorded.Body.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = recorded.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	if config.Handler != nil {
+		req.Host = recorded.Host
+		req.RemoteAddr = "192.0.2.1:1234"
+		recorder := httptest.NewRecorder()
+		config.Handler.ServeHTTP(recorder, req)
+		return &RecordedResponse{
+			Status: recorder.Code,
+			Header: recorder.Header(),
+			Body:   newRecordedBody(recorder.Body.Bytes(), false),
+		}, nil
+	}
+
+	res, err := config.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordedResponse{Status: res.StatusCode, Header: res.Header, Body: newRecordedBody(body, false)}, nil
+}
+
+// redact masks a replayed response as Record masks recorded ones.
+func (config *ReplayConfig) redact(response *RecordedResponse) *RecordedResponse {
+	return &RecordedResponse{
+		Status: response.Status,
+		Header: config.Redaction.Header(response.Header),
+		Body:   newRecordedBody(redactRecordedBody(config.Redaction, response.Header.Get("Content-Type"), response.Body.Bytes()), false),
+	}
+}
+
+// compare lists the differences between a recorded and a replayed response.
+// JSON bodies are compared by value; truncated recorded bodies by prefix.
+func (config *ReplayConfig) compare(recorded, replayed *RecordedResponse) []string {
+	var mismatches []string
+	if recorded.Status != replayed.Status {
+		mismatches = append(mismatches, fmt.Sprintf("status: recorded %d, replayed %d", recorded.Status, replayed.Status))
+	}
+	for _, name := range config.Headers {
+		if want, got := recorded.Header.Get(name), replayed.Header.Get(name); want != got {
+			mismatches = append(mismatches, fmt.Sprintf("header %s: recorded %q, replayed %q", name, want, got))
+		}
+	}
+
+	want, got := recorded.Body.Bytes(), replayed.Body.Bytes()
+	if recorded.Body.Truncated {
+		got = got[:min(len(got), len(want))]
+	}
+	if !bytes.Equal(want, got) && !equalJSON(want, got) {
+		mismatches = append(mismatches, fmt.Sprintf("body: recorded %q, replayed %q", truncateForMessage(want), truncateForMessage(got)))
+	}
+	return mismatches
+}
+
+// equalJSON reports whether a and b are JSON documents with the same value.
+func equalJSON(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// truncateForMessage shortens a body quoted in a mismatch.
+func truncateForMessage(body []byte) string {
+	const max = 200
+	if len(body) > max {
+		return string(body[:max]) + "..."
+	}
+	return string(body)
+}
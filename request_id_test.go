@@ -0,0 +1,22 @@
+package gorouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDGenerated(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if len(seen) != 32 || recorder.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("Expected a generated ID replacing the invalid one, got %q and header %q", seen, recorder.Header().Get(RequestIDHeader))
+	}
+}
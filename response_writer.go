@@ -0,0 +1,252 @@
+package gorouter
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseWriter wraps http.ResponseWriter and records what has been written,
+// so middleware can inspect the response after the handler returns.
+type ResponseWriter interface {
+	http.ResponseWriter
+
+	// Status returns the status code sent to the client, or 0 if nothing was written yet.
+	Status() int
+	// BytesWritten returns the number of body bytes written.
+	BytesWritten() int64
+	// Written reports whether the response header has been sent.
+	Written() bool
+	// TimeToFirstByte returns the time between wrapping and the first header or body write.
+	TimeToFirstByte() time.Duration
+	// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController.
+	Unwrap() http.ResponseWriter
+}
+
+// WrapResponseWriter wraps w in a ResponseWriter. If w already is a ResponseWriter
+// it is returned unchanged, so middleware can call it to access the writer
+// installed by the Router. The returned writer implements each of
+// http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher exactly when w
+// does.
+func WrapResponseWriter(w http.ResponseWriter) ResponseWriter {
+	if rw, ok := w.(ResponseWriter); ok {
+		return rw
+	}
+
+	base := &responseWriter{ResponseWriter: w, start: time.Now()}
+	_, flusher := w.(http.Flusher)
+	_, hijacker := w.(http.Hijacker)
+	_, readerFrom := w.(io.ReaderFrom)
+	_, pusher := w.(http.Pusher)
+
+	// Each combination of optional interfaces gets a type implementing exactly those.
+	switch {
+	case flusher && !hijacker && !readerFrom && !pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+		}{base, rwFlusher{base}}
+	case !flusher && hijacker && !readerFrom && !pusher:
+		return struct {
+			*responseWriter
+			rwHijacker
+		}{base, rwHijacker{base}}
+	case flusher && hijacker && !readerFrom && !pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+			rwHijacker
+		}{base, rwFlusher{base}, rwHijacker{base}}
+	case !flusher && !hijacker && readerFrom && !pusher:
+		return struct {
+			*responseWriter
+			rwReaderFrom
+		}{base, rwReaderFrom{base}}
+	case flusher && !hijacker && readerFrom && !pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+			rwReaderFrom
+		}{base, rwFlusher{base}, rwReaderFrom{base}}
+	case !flusher && hijacker && readerFrom && !pusher:
+		return struct {
+			*responseWriter
+			rwHijacker
+			rwReaderFrom
+		}{base, rwHijacker{base}, rwReaderFrom{base}}
+	case flusher && hijacker && readerFrom && !pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+			rwHijacker
+			rwReaderFrom
+		}{base, rwFlusher{base}, rwHijacker{base}, rwReaderFrom{base}}
+	case !flusher && !hijacker && !readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwPusher
+		}{base, rwPusher{base}}
+	case flusher && !hijacker && !readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+			rwPusher
+		}{base, rwFlusher{base}, rwPusher{base}}
+	case !flusher && hijacker && !readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwHijacker
+			rwPusher
+		}{base, rwHijacker{base}, rwPusher{base}}
+	case flusher && hijacker && !readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+			rwHijacker
+			rwPusher
+		}{base, rwFlusher{base}, rwHijacker{base}, rwPusher{base}}
+	case !flusher && !hijacker && readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwReaderFrom
+			rwPusher
+		}{base, rwReaderFrom{base}, rwPusher{base}}
+	case flusher && !hijacker && readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+			rwReaderFrom
+			rwPusher
+		}{base, rwFlusher{base}, rwReaderFrom{base}, rwPusher{base}}
+	case !flusher && hijacker && readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwHijacker
+			rwReaderFrom
+			rwPusher
+		}{base, rwHijacker{base}, rwReaderFrom{base}, rwPusher{base}}
+	case flusher && hijacker && readerFrom && pusher:
+		return struct {
+			*responseWriter
+			rwFlusher
+			rwHijacker
+			rwReaderFrom
+			rwPusher
+		}{base, rwFlusher{base}, rwHijacker{base}, rwReaderFrom{base}, rwPusher{base}}
+	default:
+		return base
+	}
+}
+
+// responseWriter is the basic ResponseWriter implementation.
+type responseWriter struct {
+	http.ResponseWriter
+	status    int
+	bytes     int64
+	written   bool
+	start     time.Time
+	firstByte time.Time
+}
+
+// WriteHeader records the status code and sends it once; later calls are ignored.
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.written {
+		return
+	}
+	rw.markFirstByte()
+	// Informational responses may precede the final header.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		rw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	rw.status = code
+	rw.written = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the body, sending an implicit 200 header first if needed.
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.written {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Status() int {
+	return rw.status
+}
+
+func (rw *responseWriter) BytesWritten() int64 {
+	return rw.bytes
+}
+
+func (rw *responseWriter) Written() bool {
+	return rw.written
+}
+
+func (rw *responseWriter) TimeToFirstByte() time.Duration {
+	if rw.firstByte.IsZero() {
+		return 0
+	}
+	return rw.firstByte.Sub(rw.start)
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *responseWriter) markFirstByte() {
+	if rw.firstByte.IsZero() {
+		rw.firstByte = time.Now()
+	}
+}
+
+func (rw *responseWriter) flush() {
+	if !rw.written {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// rwFlusher, rwHijacker, rwReaderFrom and rwPusher add one optional
+// interface each to the types built by WrapResponseWriter.
+type rwFlusher struct{ rw *responseWriter }
+
+func (f rwFlusher) Flush() {
+	f.rw.flush()
+}
+
+type rwHijacker struct{ rw *responseWriter }
+
+func (h rwHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw := h.rw
+	conn, buf, err := rw.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !rw.written {
+		rw.markFirstByte()
+		rw.status = http.StatusSwitchingProtocols
+		rw.written = true
+	}
+	return conn, buf, err
+}
+
+type rwReaderFrom struct{ rw *responseWriter }
+
+func (r rwReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	rw := r.rw
+	if !rw.written {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	rw.bytes += n
+	return n, err
+}
+
+type rwPusher struct{ rw *responseWriter }
+
+func (p rwPusher) Push(target string, opts *http.PushOptions) error {
+	return p.rw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
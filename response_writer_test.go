@@ -0,0 +1,136 @@
+package gorouter
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriterRecordsResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	rw := WrapResponseWriter(recorder)
+
+	if rw.Written() || rw.Status() != 0 {
+		t.Fatalf("Expected fresh writer, got written=%v status=%d", rw.Written(), rw.Status())
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.WriteHeader(http.StatusInternalServerError) // ignored
+	rw.Write([]byte("hello"))
+
+	if rw.Status() != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, rw.Status())
+	}
+	if rw.BytesWritten() != 5 {
+		t.Errorf("Expected 5 bytes written, got %d", rw.BytesWritten())
+	}
+	if rw.TimeToFirstByte() < 0 {
+		t.Errorf("Expected non-negative time to first byte, got %s", rw.TimeToFirstByte())
+	}
+	if recorder.Code != http.StatusCreated {
+		t.Errorf("Expected recorder status %d, got %d", http.StatusCreated, recorder.Code)
+	}
+	if WrapResponseWriter(rw) != rw {
+		t.Error("Expected wrapping a ResponseWriter to return it unchanged")
+	}
+}
+
+func TestResponseWriterPreservesInterfaces(t *testing.T) {
+	rw := WrapResponseWriter(httptest.NewRecorder())
+	if _, ok := rw.(http.Flusher); !ok {
+		t.Error("Expected http.Flusher to be preserved")
+	}
+	if _, ok := rw.(http.Hijacker); ok {
+		t.Error("Expected http.Hijacker not to be added")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rw := WrapResponseWriter(w)
+		_, flusher := rw.(http.Flusher)
+		_, hijacker := rw.(http.Hijacker)
+		_, readerFrom := rw.(io.ReaderFrom)
+		if !flusher || !hijacker || !readerFrom {
+			t.Errorf("Expected flusher, hijacker and reader from, got %v %v %v", flusher, hijacker, readerFrom)
+		}
+		io.Copy(rw, strings.NewReader("copied"))
+		if rw.BytesWritten() != 6 {
+			t.Errorf("Expected 6 bytes written, got %d", rw.BytesWritten())
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// hijackOnlyWriter supports hijacking but neither flushing nor ReadFrom.
+type hijackOnlyWriter struct {
+	http.ResponseWriter
+}
+
+func (hijackOnlyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("not a connection")
+}
+
+// pushOnlyWriter supports server push only.
+type pushOnlyWriter struct {
+	http.ResponseWriter
+}
+
+func (pushOnlyWriter) Push(target string, opts *http.PushOptions) error {
+	return nil
+}
+
+func TestResponseWriterInterfaceCombinations(t *testing.T) {
+	tests := []struct {
+		w                                     http.ResponseWriter
+		flusher, hijacker, readerFrom, pusher bool
+	}{
+		{hijackOnlyWriter{httptest.NewRecorder()}, false, true, false, false},
+		{pushOnlyWriter{httptest.NewRecorder()}, false, false, false, true},
+		{struct{ http.ResponseWriter }{httptest.NewRecorder()}, false, false, false, false},
+	}
+	for i, tt := range tests {
+		rw := WrapResponseWriter(tt.w)
+		_, flusher := rw.(http.Flusher)
+		_, hijacker := rw.(http.Hijacker)
+		_, readerFrom := rw.(io.ReaderFrom)
+		_, pusher := rw.(http.Pusher)
+		if flusher != tt.flusher || hijacker != tt.hijacker || readerFrom != tt.readerFrom || pusher != tt.pusher {
+			t.Errorf("%d: expected interfaces %v %v %v %v, got %v %v %v %v", i,
+				tt.flusher, tt.hijacker, tt.readerFrom, tt.pusher, flusher, hijacker, readerFrom, pusher)
+		}
+	}
+}
+
+func TestRouterExposesResponseWriter(t *testing.T) {
+	var status int
+	var size int64
+
+	r := NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req)
+			rw := WrapResponseWriter(w)
+			status, size = rw.Status(), rw.BytesWritten()
+		})
+	})
+	r.AddRoute(http.MethodGet, "/teapot", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/teapot", nil))
+
+	if status != http.StatusTeapot || size != 15 {
+		t.Errorf("Expected status %d and 15 bytes, got %d and %d", http.StatusTeapot, status, size)
+	}
+}
@@ -0,0 +1,197 @@
+package gorouter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFileConfig represents configuration options for a RotatingFile.
+type RotatingFileConfig struct {
+	Filename   string        // Path of the current file; rotated files get a timestamp before the extension
+	MaxSize    int64         // Rotate before the file grows past this many bytes; zero disables
+	Interval   time.Duration // Rotate once the file has been written for this long; zero disables
+	MaxBackups int           // Rotated files kept, oldest removed first; zero keeps all
+}
+
+// RotatingFile is an io.Writer appending to a file that is rotated by size
+// or age, e.g. for AccessLog. It is safe for concurrent use.
+type RotatingFile struct {
+	config RotatingFileConfig
+	now    func() time.Time
+	rename func(oldpath, newpath string) error
+	mu     sync.Mutex
+	file   *os.File // nil after Close, or if reopening after a failed rotation failed
+	closed bool
+	size   int64
+	opened time.Time
+}
+
+// rotatedTimeFormat is the timestamp added to rotated file names; it sorts
+// chronologically.
+const rotatedTimeFormat = "20060102T150405.000"
+
+// NewRotatingFile opens (or creates) the file for appending.
+func NewRotatingFile(config RotatingFileConfig) (*RotatingFile, error) {
+	if config.Filename == "" {
+		return nil, errors.New("gorouter: RotatingFile needs a Filename")
+	}
+	f := &RotatingFile{config: config, now: time.Now, rename: os.Rename}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write appends p to the file, rotating it first if needed. A single write
+// is never split across files. If rotating fails, p is still appended to the
+// current file and the rotation error is returned.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	var rotateErr error
+	tooBig := f.config.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.config.MaxSize
+	tooOld := f.config.Interval > 0 && f.now().Sub(f.opened) >= f.config.Interval
+	if tooBig || tooOld {
+		if rotateErr = f.rotate(); f.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate moves the current file aside and starts a new one, e.g. on SIGHUP.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	return f.rotate()
+}
+
+// Close closes the current file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.config.Filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// rotate moves the current file aside and opens a new one. If the file
+// cannot be moved, the current file is reopened for appending so later
+// writes are not lost.
+func (f *RotatingFile) rotate() error {
+	closeErr := f.file.Close()
+	f.file = nil
+	base, ext := f.nameParts()
+	stamp := f.now()
+	rotated := base + "-" + stamp.Format(rotatedTimeFormat) + ext
+	// Never overwrite a backup rotated in the same millisecond.
+	for _, err := os.Lstat(rotated); err == nil; _, err = os.Lstat(rotated) {
+		stamp = stamp.Add(time.Millisecond)
+		rotated = base + "-" + stamp.Format(rotatedTimeFormat) + ext
+	}
+	if err := f.rename(f.config.Filename, rotated); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Join(err, f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return errors.Join(closeErr, f.removeOldBackups())
+}
+
+// nameParts splits the file name around where rotated files get their timestamp.
+func (f *RotatingFile) nameParts() (base, ext string) {
+	ext = filepath.Ext(f.config.Filename)
+	return strings.TrimSuffix(f.config.Filename, ext), ext
+}
+
+// Backups returns the rotated files, oldest first.
+func (f *RotatingFile) Backups() ([]string, error) {
+	base, ext := f.nameParts()
+	dir, prefix := filepath.Dir(base), filepath.Base(base)+"-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(rotatedTimeFormat, stamp); err == nil {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func (f *RotatingFile) removeOldBackups() error {
+	if f.config.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > f.config.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
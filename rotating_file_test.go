@@ -0,0 +1,98 @@
+package gorouter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	filename := filepath.Join(t.TempDir(), "logs", "access.log")
+	file, err := NewRotatingFile(RotatingFileConfig{Filename: filename, MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.now = func() time.Time { return now }
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, _ := file.Backups()
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups to be kept, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "second\n" {
+		t.Errorf("Expected the oldest backup to be removed, got %q", data)
+	}
+	if filepath.Base(backups[1]) != "access-20240102T030405.002.log" {
+		t.Errorf("Expected backups rotated together to get distinct names, got %s", backups[1])
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "fourth\n" {
+		t.Errorf("Expected the current file to hold the last write, got %q", data)
+	}
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	filename := filepath.Join(t.TempDir(), "access.log")
+	file, _ := NewRotatingFile(RotatingFileConfig{Filename: filename, Interval: time.Hour})
+	defer file.Close()
+	file.now = func() time.Time { return now }
+	file.opened = now
+
+	file.Write([]byte("a\n"))
+	now = now.Add(time.Hour)
+	file.Write([]byte("b\n"))
+
+	backups, _ := file.Backups()
+	if len(backups) != 1 || filepath.Base(backups[0]) != "access-20240102T010000.000.log" {
+		t.Errorf("Expected one hourly backup, got %v", backups)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte("c\n")); err == nil {
+		t.Error("Expected writes after Close to fail")
+	}
+}
+
+func TestRotatingFileRotationFailure(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	file, err := NewRotatingFile(RotatingFileConfig{Filename: filename, MaxSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.rename = func(oldpath, newpath string) error { return os.ErrPermission }
+
+	file.Write([]byte("abc\n"))
+	if _, err := file.Write([]byte("def\n")); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected the rotation error, got %v", err)
+	}
+	if err := file.Rotate(); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected the rotation error, got %v", err)
+	}
+
+	// The current file stays open, so writes keep landing once rotation works.
+	file.rename = os.Rename
+	if _, err := file.Write([]byte("ghi\n")); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := file.Backups()
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "abc\ndef\n" {
+		t.Errorf("Expected the writes during the failure to be kept, got %q", data)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "ghi\n" {
+		t.Errorf("Expected the current file to hold the last write, got %q", data)
+	}
+}
@@ -3,6 +3,7 @@ package gorouter
 import (
 	"context"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -12,6 +13,14 @@ type Router struct {
 	middleware         []Middleware
 	routes             map[string]map[string]routeHandler
 	globalDependencies *DependencyRegistry
+	errorRenderer      ErrorRenderer
+	errorMappings      []errorMatcher
+	encoders           []mediaEncoder
+	jsonConfig         *JSONConfig
+	decoders           map[string]Decoder
+	maxBodySize        int64
+	logger             Logger
+	redaction          *RedactionPolicy
 }
 
 // routeHandler holds the handler and its specific dependencies
@@ -46,7 +55,7 @@ func (r *Router) Use(middleware ...Middleware) {
 
 // AddRoute adds a new route to the router.
 func (r *Router) AddRoute(method, path string, handler http.HandlerFunc, middleware ...Middleware) {
-	finalHandler := ApplyMiddleware(handler, append(r.middleware, middleware...)...)
+	finalHandler := ApplyMiddleware(handler, middleware...)
 
 	if r.routes[method] == nil {
 		r.routes[method] = make(map[string]routeHandler)
@@ -60,7 +69,7 @@ func (r *Router) AddRoute(method, path string, handler http.HandlerFunc, middlew
 
 // AddRouteWithDependencies adds a new route to the router with route-specific dependencies.
 func (r *Router) AddRouteWithDependencies(method, path string, handler http.HandlerFunc, dependencies *DependencyRegistry, middleware ...Middleware) {
-	finalHandler := ApplyMiddleware(handler, append(r.middleware, middleware...)...)
+	finalHandler := ApplyMiddleware(handler, middleware...)
 
 	if r.routes[method] == nil {
 		r.routes[method] = make(map[string]routeHandler)
@@ -74,33 +83,68 @@ func (r *Router) AddRouteWithDependencies(method, path string, handler http.Hand
 
 // ServeHTTP handles incoming HTTP requests and dispatches them to the appropriate handlers.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Wrap the writer once so every middleware can inspect the response.
+	w = WrapResponseWriter(w)
+
 	params := parseParams(req)
 	ctx := req.Context()
 	ctx = context.WithValue(ctx, ParamsContextKey, params)
+	ctx = context.WithValue(ctx, routerKey{}, r)
+	info := &routeInfo{}
+	ctx = context.WithValue(ctx, routeInfoKey{}, info)
 	req = req.WithContext(ctx)
 
-	finalHandler := ApplyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		// Try to match the exact path first
-		if rh, ok := r.routes[req.Method][req.URL.Path]; ok {
-			mergedHandler := r.mergeHandlersWithDependencies(rh.handler, rh.dependencyRegistry)
-			mergedHandler(w, req)
-			return
+	// Match the route before the router middleware runs, so every middleware
+	// can read the route pattern. The router middleware wraps the matched
+	// handler here only, so it runs once per request.
+	handler, pattern, matchedParams := r.match(req.Method, req.URL.Path)
+	info.pattern = pattern
+	if matchedParams != nil {
+		req = req.WithContext(context.WithValue(req.Context(), ParamsContextKey, matchedParams))
+	}
+	ApplyMiddleware(handler, r.middleware...).ServeHTTP(w, req)
+}
+
+// match returns the handler of the route matching method and path, with the
+// route pattern and the path parameters, or a handler rendering 404 or 405.
+func (r *Router) match(method, path string) (http.Handler, string, Params) {
+	// Try to match the exact path first
+	if rh, ok := r.routes[method][path]; ok {
+		return r.mergeHandlersWithDependencies(rh.handler, rh.dependencyRegistry), path, nil
+	}
+
+	// Try to match with parameters in the path
+	for routePath, rh := range r.routes[method] {
+		if matched, parsedParams := matchPathWithParams(routePath, path); matched {
+			return r.mergeHandlersWithDependencies(rh.handler, rh.dependencyRegistry), routePath, parsedParams
 		}
+	}
 
-		// Try to match with parameters in the path
-		for path, rh := range r.routes[req.Method] {
-			if matched, parsedParams := matchPathWithParams(path, req.URL.Path); matched {
-				req = req.WithContext(context.WithValue(req.Context(), ParamsContextKey, parsedParams))
-				mergedHandler := r.mergeHandlersWithDependencies(rh.handler, rh.dependencyRegistry)
-				mergedHandler(w, req)
-				return
-			}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if allowed := r.allowedMethods(req.URL.Path); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			RenderError(w, req, NewHTTPError(http.StatusMethodNotAllowed, ""))
+			return
 		}
+		RenderError(w, req, NewHTTPError(http.StatusNotFound, ""))
+	}), "", nil
+}
+
+// routeInfo records the route matched for a request. It is stored in the
+// request context before any middleware runs.
+type routeInfo struct {
+	pattern string
+}
 
-		http.NotFound(w, req)
-	}), r.middleware...)
+type routeInfoKey struct{}
 
-	finalHandler.ServeHTTP(w, req)
+// RoutePattern returns the registered path pattern (e.g. "/users/:id") that
+// matched the request, or an empty string if no route matched yet.
+func RoutePattern(req *http.Request) string {
+	if info, ok := req.Context().Value(routeInfoKey{}).(*routeInfo); ok {
+		return info.pattern
+	}
+	return ""
 }
 
 // mergeHandlersWithDependencies merges global and route-specific dependencies
@@ -122,6 +166,25 @@ func (r *Router) mergeHandlersWithDependencies(handler http.HandlerFunc, routeDe
 	}
 }
 
+// allowedMethods returns the sorted methods that have a route matching the path.
+func (r *Router) allowedMethods(path string) []string {
+	var allowed []string
+	for method, routes := range r.routes {
+		if _, ok := routes[path]; ok {
+			allowed = append(allowed, method)
+			continue
+		}
+		for routePath := range routes {
+			if matched, _ := matchPathWithParams(routePath, path); matched {
+				allowed = append(allowed, method)
+				break
+			}
+		}
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
 // matchPathWithParams matches the path with parameters against the request path.
 func matchPathWithParams(routePath, requestPath string) (bool, Params) {
 	routeParts := strings.Split(routePath, "/")
@@ -66,6 +66,29 @@ func TestRouterWithRouteGroup(t *testing.T) {
 	checkResponse(t, recorder, http.StatusOK, "GET /admin/dashboard", "", "")
 }
 
+func TestRouterMiddlewareRunsOnce(t *testing.T) {
+	var calls []string
+	r := NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			calls = append(calls, RoutePattern(req))
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.AddRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(PathParam(req, "id")))
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	checkResponse(t, recorder, http.StatusOK, "7", "", "")
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if len(calls) != 2 || calls[0] != "/users/:id" || calls[1] != "" {
+		t.Errorf("Expected the middleware to run once per request with the route pattern, got %q", calls)
+	}
+}
+
 func checkResponse(t *testing.T, recorder *httptest.ResponseRecorder, expectedStatus int, expectedBody string, expectedHeaderKey, expectedHeaderValue string) {
 	t.Helper()
 
@@ -44,6 +44,11 @@ func CORSMiddleware(options CORSOptions) func(http.Handler) http.Handler {
 	}
 }
 
+// AllowsOrigin reports whether origin is one of the allowed origins.
+func (options CORSOptions) AllowsOrigin(origin string) bool {
+	return isOriginAllowed(options.AllowedOrigins, origin)
+}
+
 // isOriginAllowed checks if the origin is allowed based on the allowed origins list.
 func isOriginAllowed(allowedOrigins []string, origin string) bool {
 	for _, allowed := range allowedOrigins {
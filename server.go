@@ -1,10 +1,12 @@
 package gorouter
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,6 +17,7 @@ import (
 // Server is a wrapper around http.Server.
 type Server struct {
 	*http.Server
+	logger Logger
 }
 
 // ServerConfig represents configuration options for the gorouter server.
@@ -24,6 +27,7 @@ type ServerConfig struct {
 	WriteTimeout time.Duration // Write timeout for outgoing responses
 	IdleTimeout  time.Duration // Idle timeout for keep-alive connections
 	TLSConfig    TLSConfig     // TLS/SSL configuration
+	Logger       Logger        `json:"-"` // Logger for server events and http.Server errors; defaults to slog.Default()
 }
 
 // TLSConfig represents TLS/SSL configuration options.
@@ -34,12 +38,17 @@ type TLSConfig struct {
 
 // NewServer creates a new instance of the gorouter server with the given configuration.
 func NewServer(handler http.Handler, config ServerConfig) *Server {
+	logger := config.Logger
+	if logger == nil {
+		logger = defaultLogger
+	}
 	server := &http.Server{
 		Addr:         config.Addr,
 		Handler:      handler,
 		ReadTimeout:  config.ReadTimeout,
 		WriteTimeout: config.WriteTimeout,
 		IdleTimeout:  config.IdleTimeout,
+		ErrorLog:     log.New(logWriter{logger, slog.LevelError}, "", 0),
 	}
 
 	if config.TLSConfig.CertFile != "" && config.TLSConfig.KeyFile != "" {
@@ -48,19 +57,21 @@ func NewServer(handler http.Handler, config ServerConfig) *Server {
 		var err error
 		tlsConfig.Certificates[0], err = tls.LoadX509KeyPair(config.TLSConfig.CertFile, config.TLSConfig.KeyFile)
 		if err != nil {
-			log.Fatalf("Error loading TLS certificate: %v", err)
+			logger.Log(context.Background(), slog.LevelError, "Error loading TLS certificate", "error", err)
+			os.Exit(1)
 		}
 		server.TLSConfig = tlsConfig
 	}
 
 	return &Server{
 		Server: server,
+		logger: logger,
 	}
 }
 
 // Start starts the gorouter server.
 func (s *Server) Start() error {
-	log.Printf("Server starting on address %s...", s.Addr)
+	s.log(slog.LevelInfo, "Server starting", "addr", s.Addr)
 	if s.TLSConfig != nil {
 		return s.ListenAndServeTLS("", "")
 	}
@@ -92,7 +103,7 @@ func LoadConfig(file string) (*Config, error) {
 
 // Stop stops the gorouter server gracefully.
 func (s *Server) Stop() error {
-	log.Println("Server shutting down gracefully...")
+	s.log(slog.LevelInfo, "Server shutting down gracefully")
 	return s.Shutdown(nil)
 }
 
@@ -103,10 +114,20 @@ func GracefulShutdown(server *Server) {
 
 	<-sigCh // Wait for termination signal
 
-	log.Println("Shutting down server...")
+	server.log(slog.LevelInfo, "Shutting down server")
 
 	if err := server.Stop(); err != nil {
-		log.Fatalf("Error shutting down server: %v", err)
+		server.log(slog.LevelError, "Error shutting down server", "error", err)
+		os.Exit(1)
+	}
+	server.log(slog.LevelInfo, "Server gracefully stopped")
+}
+
+// log writes a server event with the server's logger.
+func (s *Server) log(level slog.Level, msg string, args ...interface{}) {
+	logger := s.logger
+	if logger == nil {
+		logger = defaultLogger
 	}
-	log.Println("Server gracefully stopped")
+	logger.Log(context.Background(), level, msg, args...)
 }
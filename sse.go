@@ -0,0 +1,309 @@
+package gorouter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEHeartbeat is the heartbeat interval used when SSEOptions leaves it unset.
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	ID    string        // Sets the client's Last-Event-ID; empty keeps the previous one
+	Event string        // Event type; empty means "message"
+	Data  interface{}   // Strings and []byte are sent as is, other values as JSON
+	Retry time.Duration // Reconnection delay for the client; zero leaves it unchanged
+}
+
+// SSEOptions represents configuration options for Server-Sent Events streams.
+type SSEOptions struct {
+	Heartbeat time.Duration // Interval between keep-alive comments; defaults to DefaultSSEHeartbeat, negative disables
+	Retry     time.Duration // Reconnection delay sent when the stream opens
+	Replay    ReplayBuffer  // Stores sent events and replays them to clients resuming with Last-Event-ID
+}
+
+// ReplayBuffer stores events so reconnecting clients can resume where they left off.
+type ReplayBuffer interface {
+	// Add stores an event with a non-empty ID. Streams sharing a buffer may add
+	// the same event more than once; events with a stored ID must be ignored.
+	Add(event Event)
+	// Since returns the events stored after the one with the given ID. ok is
+	// false if that event is no longer, or never was, in the buffer.
+	Since(id string) (events []Event, ok bool)
+}
+
+// MemoryReplayBuffer is a ReplayBuffer keeping the most recent events in memory.
+type MemoryReplayBuffer struct {
+	mu     sync.Mutex
+	size   int
+	events []Event
+	ids    map[string]bool
+}
+
+// NewMemoryReplayBuffer creates a MemoryReplayBuffer holding up to size events.
+func NewMemoryReplayBuffer(size int) *MemoryReplayBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &MemoryReplayBuffer{size: size, ids: make(map[string]bool)}
+}
+
+// Add stores the event, dropping the oldest one when the buffer is full.
+func (b *MemoryReplayBuffer) Add(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if event.ID == "" || b.ids[event.ID] {
+		return
+	}
+	if len(b.events) == b.size {
+		delete(b.ids, b.events[0].ID)
+		b.events = b.events[1:]
+	}
+	b.events = append(b.events, event)
+	b.ids[event.ID] = true
+}
+
+// Since returns the events stored after the one with the given ID.
+func (b *MemoryReplayBuffer) Since(id string) ([]Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, event := range b.events {
+		if event.ID == id {
+			return append([]Event(nil), b.events[i+1:]...), true
+		}
+	}
+	return nil, false
+}
+
+// SSEStream is an open text/event-stream response. It is safe for concurrent use.
+type SSEStream struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	req         *http.Request
+	rc          *http.ResponseController
+	replay      ReplayBuffer
+	lastEventID string
+	closed      bool
+	stop        chan struct{}
+}
+
+// NewSSEStream sends the event stream response header and returns the stream.
+// Events stored in opts.Replay after the request's Last-Event-ID are sent
+// right away. A heartbeat comment is sent periodically until Close is called
+// or the client disconnects.
+func NewSSEStream(w http.ResponseWriter, req *http.Request, opts SSEOptions) (*SSEStream, error) {
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's WriteTimeout.
+	rc.SetWriteDeadline(time.Time{})
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &SSEStream{
+		w:           w,
+		req:         req,
+		rc:          rc,
+		replay:      opts.Replay,
+		lastEventID: req.Header.Get("Last-Event-ID"),
+		stop:        make(chan struct{}),
+	}
+
+	if opts.Retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", opts.Retry.Milliseconds())
+	}
+	if s.replay != nil && s.lastEventID != "" {
+		if events, ok := s.replay.Since(s.lastEventID); ok {
+			for _, event := range events {
+				if err := s.write(event); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return nil, fmt.Errorf("gorouter: streaming not supported: %w", err)
+	}
+
+	heartbeat := opts.Heartbeat
+	if heartbeat == 0 {
+		heartbeat = DefaultSSEHeartbeat
+	}
+	if heartbeat > 0 {
+		go s.heartbeat(heartbeat)
+	}
+	return s, nil
+}
+
+// Send writes an event and flushes it to the client.
+func (s *SSEStream) Send(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("gorouter: send on closed event stream")
+	}
+	if err := s.req.Context().Err(); err != nil {
+		return err
+	}
+	if s.replay != nil && event.ID != "" {
+		s.replay.Add(event)
+	}
+	if err := s.write(event); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Comment writes a comment line, which clients ignore.
+func (s *SSEStream) Comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("gorouter: send on closed event stream")
+	}
+	for _, line := range splitLines(text) {
+		if _, err := fmt.Fprintf(s.w, ": %s\n", line); err != nil {
+			return err
+		}
+	}
+	if _, err := s.w.Write([]byte{'\n'}); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// LastEventID returns the ID of the last event sent, or the Last-Event-ID the
+// client resumed from if nothing was sent yet.
+func (s *SSEStream) LastEventID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastEventID
+}
+
+// Done is closed when the client disconnects.
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.req.Context().Done()
+}
+
+// Close stops the heartbeat. Further sends fail.
+func (s *SSEStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+}
+
+// heartbeat sends keep-alive comments until the stream is closed.
+func (s *SSEStream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Comment("heartbeat"); err != nil {
+				return
+			}
+		case <-s.stop:
+			return
+		case <-s.Done():
+			return
+		}
+	}
+}
+
+// write encodes an event in the text/event-stream format. The caller must hold s.mu
+// or own the stream exclusively.
+func (s *SSEStream) write(event Event) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	// IDs with NUL are ignored by clients; newlines would break the framing.
+	if id := stripNewlines(event.ID); id != "" && !strings.ContainsRune(id, 0) {
+		buf.WriteString("id: " + id + "\n")
+		s.lastEventID = id
+	}
+	if name := stripNewlines(event.Event); name != "" {
+		buf.WriteString("event: " + name + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	data, err := eventData(jsonConfigFrom(s.req.Context()), event.Data)
+	if err != nil {
+		return err
+	}
+	for _, line := range splitLines(data) {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteByte('\n')
+
+	_, err = s.w.Write(buf.Bytes())
+	return err
+}
+
+// eventData converts event data to text.
+func eventData(config *JSONConfig, data interface{}) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	var buf bytes.Buffer
+	if err := config.encodeCompact(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// splitLines splits text on any of the line endings allowed by the event stream format.
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n")
+}
+
+// stripNewlines removes line breaks from a single-line field.
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// SSEHandlerFunc handles a Server-Sent Events stream. It should return when
+// the stream's Done channel is closed.
+type SSEHandlerFunc func(stream *SSEStream, req *http.Request) error
+
+// SSEHandler adapts an SSEHandlerFunc to an http.HandlerFunc. Errors returned
+// after the client disconnected are ignored; others are logged, as the
+// response has already started.
+func SSEHandler(opts SSEOptions, handler SSEHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		stream, err := NewSSEStream(w, req, opts)
+		if err != nil {
+			RenderError(w, req, err)
+			return
+		}
+		defer stream.Close()
+		if err := handler(stream, req); err != nil && req.Context().Err() == nil {
+			logRequestError(req, err, slog.LevelError)
+		}
+	}
+}
+
+// SSE adds a GET route serving a Server-Sent Events stream to the route group.
+func (rg *RouteGroup) SSE(path string, opts SSEOptions, handler SSEHandlerFunc, middleware ...Middleware) {
+	rg.GET(path, SSEHandler(opts, handler), middleware...)
+}
@@ -0,0 +1,115 @@
+package gorouter
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEEventFormat(t *testing.T) {
+	handler := SSEHandler(SSEOptions{Heartbeat: -1, Retry: 3 * time.Second}, func(stream *SSEStream, req *http.Request) error {
+		stream.Send(Event{ID: "1", Event: "greeting", Data: "hello\nworld"})
+		stream.Send(Event{ID: "2", Data: map[string]int{"n": 2}, Retry: time.Second})
+		return stream.Comment("done")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	expected := "retry: 3000\n\n" +
+		"id: 1\nevent: greeting\ndata: hello\ndata: world\n\n" +
+		"id: 2\nretry: 1000\ndata: {\"n\":2}\n\n" +
+		": done\n\n"
+	checkResponse(t, recorder, http.StatusOK, expected, "Content-Type", "text/event-stream")
+	if cc := recorder.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Expected Cache-Control no-cache, got %q", cc)
+	}
+}
+
+func TestSSEReplay(t *testing.T) {
+	replay := NewMemoryReplayBuffer(2)
+	handler := SSEHandler(SSEOptions{Heartbeat: -1, Replay: replay}, func(stream *SSEStream, req *http.Request) error {
+		if stream.LastEventID() == "" {
+			for _, id := range []string{"1", "2", "3"} {
+				stream.Send(Event{ID: id, Data: id})
+			}
+		}
+		return nil
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+	checkResponse(t, recorder, http.StatusOK, "id: 3\ndata: 3\n\n", "", "")
+
+	// Event 1 was evicted, so nothing can be replayed.
+	req.Header.Set("Last-Event-ID", "1")
+	recorder = httptest.NewRecorder()
+	handler(recorder, req)
+	checkResponse(t, recorder, http.StatusOK, "", "", "")
+}
+
+func TestMemoryReplayBufferIgnoresDuplicates(t *testing.T) {
+	replay := NewMemoryReplayBuffer(10)
+	replay.Add(Event{ID: "a"})
+	replay.Add(Event{ID: "b"})
+	replay.Add(Event{ID: "b"})
+	replay.Add(Event{Data: "no id"})
+
+	events, ok := replay.Since("a")
+	if !ok || len(events) != 1 || events[0].ID != "b" {
+		t.Errorf("Expected only event b after a, got %v (ok=%v)", events, ok)
+	}
+}
+
+func TestSSERouteGroupDisconnect(t *testing.T) {
+	stopped := make(chan struct{})
+	router := NewRouter()
+	router.Group("/api").SSE("/ticks", SSEOptions{Heartbeat: 10 * time.Millisecond}, func(stream *SSEStream, req *http.Request) error {
+		defer close(stopped)
+		stream.Send(Event{Event: "tick", Data: "1"})
+		<-stream.Done()
+		return stream.Send(Event{Data: "after disconnect"})
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/ticks", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 4 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if strings.Join(lines[:3], "|") != "event: tick|data: 1|" {
+		t.Errorf("Unexpected event lines %q", lines[:3])
+	}
+	if lines[3] != ": heartbeat" {
+		t.Errorf("Expected a heartbeat, got %q", lines[3])
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Handler did not observe the disconnect")
+	}
+}
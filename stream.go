@@ -0,0 +1,218 @@
+package gorouter
+
+import (
+	"bytes"
+	"errors"
+	"iter"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// StreamFormat selects how a JSONStream frames its items.
+type StreamFormat int
+
+const (
+	StreamJSONArray StreamFormat = iota // A single JSON array, application/json
+	StreamNDJSON                        // One JSON value per line, application/x-ndjson
+)
+
+// StreamErrorTrailer is the trailer set to the error message when a stream
+// fails after its first item was sent.
+const StreamErrorTrailer = "X-Stream-Error"
+
+// StreamOptions represents configuration options for streaming responses.
+type StreamOptions struct {
+	Format        StreamFormat  // Framing of the items; defaults to StreamJSONArray
+	FlushEvery    int           // Flush after this many items; defaults to 1
+	FlushInterval time.Duration // Flush when this much time passed since the last flush
+}
+
+// JSONStream writes a response item by item instead of encoding it all at once.
+//
+// Errors before the first item are rendered as a normal error response. Once
+// the stream has started, an error is reported in-band: the final item is an
+// object with a single "error" member, followed by the closing bracket for
+// arrays, and the StreamErrorTrailer trailer carries the message. Streams stop
+// silently when the request context is cancelled.
+type JSONStream struct {
+	w         http.ResponseWriter
+	req       *http.Request
+	rc        *http.ResponseController
+	opts      StreamOptions
+	started   bool
+	closed    bool
+	items     int
+	pending   int
+	lastFlush time.Time
+}
+
+// NewJSONStream creates a JSONStream. Nothing is written until the first item or Close.
+func NewJSONStream(w http.ResponseWriter, req *http.Request, opts StreamOptions) *JSONStream {
+	if opts.FlushEvery <= 0 {
+		opts.FlushEvery = 1
+	}
+	return &JSONStream{
+		w:    w,
+		req:  req,
+		rc:   http.NewResponseController(w),
+		opts: opts,
+	}
+}
+
+// Write encodes and sends one item.
+func (s *JSONStream) Write(v interface{}) error {
+	if s.closed {
+		return errors.New("gorouter: write to closed stream")
+	}
+	if err := s.req.Context().Err(); err != nil {
+		return err
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := jsonConfigFrom(s.req.Context()).encodeCompact(buf, v); err != nil {
+		return err
+	}
+
+	s.start()
+	if _, err := s.w.Write(s.frame(buf.Bytes())); err != nil {
+		return err
+	}
+	s.items++
+	s.pending++
+	if s.pending >= s.opts.FlushEvery || (s.opts.FlushInterval > 0 && time.Since(s.lastFlush) >= s.opts.FlushInterval) {
+		s.Flush()
+	}
+	return nil
+}
+
+// Flush sends buffered items to the client.
+func (s *JSONStream) Flush() {
+	if !s.started {
+		return
+	}
+	s.rc.Flush()
+	s.pending = 0
+	s.lastFlush = time.Now()
+}
+
+// Close ends the stream. A non-nil err is reported as described on JSONStream,
+// unless the request context was cancelled. Close returns err.
+func (s *JSONStream) Close(err error) error {
+	if s.closed {
+		return err
+	}
+	s.closed = true
+
+	if err != nil && s.req.Context().Err() != nil {
+		return err
+	}
+	if err != nil && !s.started {
+		RenderError(s.w, s.req, err)
+		return err
+	}
+
+	s.start()
+	if err != nil {
+		message := toHTTPError(err).Message
+		buf := getBuffer()
+		defer putBuffer(buf)
+		jsonConfigFrom(s.req.Context()).encodeCompact(buf, map[string]string{"error": message})
+		s.w.Write(s.frame(buf.Bytes()))
+		s.w.Header().Set(StreamErrorTrailer, message)
+		logRequestError(s.req, err, slog.LevelError)
+	}
+	if s.opts.Format == StreamJSONArray {
+		s.w.Write([]byte("]\n"))
+	}
+	s.Flush()
+	return err
+}
+
+// start sends the response header and the opening bracket of arrays.
+func (s *JSONStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	header := s.w.Header()
+	if s.opts.Format == StreamNDJSON {
+		header.Set("Content-Type", "application/x-ndjson")
+	} else {
+		header.Set("Content-Type", "application/json")
+	}
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Add("Trailer", StreamErrorTrailer)
+	s.w.WriteHeader(http.StatusOK)
+	if s.opts.Format == StreamJSONArray {
+		s.w.Write([]byte{'['})
+	}
+	s.lastFlush = time.Now()
+}
+
+// frame prepares an encoded item for the stream format: array items are
+// separated by commas and NDJSON items end with exactly one newline.
+func (s *JSONStream) frame(item []byte) []byte {
+	item = bytes.TrimRight(item, "\n")
+	if s.opts.Format == StreamNDJSON {
+		return append(item, '\n')
+	}
+	if s.items > 0 {
+		return append([]byte{','}, item...)
+	}
+	return item
+}
+
+// StreamSeq streams the values of seq and closes the stream.
+func StreamSeq[T any](w http.ResponseWriter, req *http.Request, seq iter.Seq[T], opts StreamOptions) error {
+	stream := NewJSONStream(w, req, opts)
+	for v := range seq {
+		if err := stream.Write(v); err != nil {
+			return stream.Close(err)
+		}
+	}
+	return stream.Close(nil)
+}
+
+// StreamSeq2 streams the values of seq until it yields a non-nil error, which
+// is reported as a mid-stream error.
+func StreamSeq2[T any](w http.ResponseWriter, req *http.Request, seq iter.Seq2[T, error], opts StreamOptions) error {
+	stream := NewJSONStream(w, req, opts)
+	for v, err := range seq {
+		if err != nil {
+			return stream.Close(err)
+		}
+		if err := stream.Write(v); err != nil {
+			return stream.Close(err)
+		}
+	}
+	return stream.Close(nil)
+}
+
+// StreamChan streams values received from ch until it is closed or the request
+// context is cancelled. Pending items are flushed whenever ch has nothing ready.
+func StreamChan[T any](w http.ResponseWriter, req *http.Request, ch <-chan T, opts StreamOptions) error {
+	stream := NewJSONStream(w, req, opts)
+	ctx := req.Context()
+	for {
+		var v T
+		var ok bool
+		select {
+		case v, ok = <-ch:
+		default:
+			stream.Flush()
+			select {
+			case v, ok = <-ch:
+			case <-ctx.Done():
+				return stream.Close(ctx.Err())
+			}
+		}
+		if !ok {
+			return stream.Close(nil)
+		}
+		if err := stream.Write(v); err != nil {
+			return stream.Close(err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package gorouter
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestStreamSeqJSONArray(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	StreamSeq(recorder, req, slices.Values([]int{1, 2, 3}), StreamOptions{})
+
+	checkResponse(t, recorder, http.StatusOK, "[1,2,3]\n", "Content-Type", "application/json")
+	if !recorder.Flushed {
+		t.Error("Expected the stream to be flushed")
+	}
+}
+
+func TestStreamSeqEmpty(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	StreamSeq(recorder, req, slices.Values([]int{}), StreamOptions{})
+
+	checkResponse(t, recorder, http.StatusOK, "[]\n", "", "")
+}
+
+func TestStreamSeqNDJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	items := []map[string]int{{"n": 1}, {"n": 2}}
+	StreamSeq(recorder, req, slices.Values(items), StreamOptions{Format: StreamNDJSON})
+
+	checkResponse(t, recorder, http.StatusOK, "{\"n\":1}\n{\"n\":2}\n", "Content-Type", "application/x-ndjson")
+}
+
+// failingSeq yields n values and then err.
+func failingSeq(n int, err error) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(i, nil) {
+				return
+			}
+		}
+		yield(0, err)
+	}
+}
+
+func TestStreamSeq2MidStreamError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	err := StreamSeq2(recorder, req, failingSeq(2, NewHTTPError(http.StatusBadGateway, "upstream failed")), StreamOptions{})
+	if err == nil {
+		t.Fatal("Expected StreamSeq2 to return the error")
+	}
+
+	checkResponse(t, recorder, http.StatusOK, `[1,2,{"error":"upstream failed"}]`+"\n", "", "")
+	if got := recorder.Result().Trailer.Get(StreamErrorTrailer); got != "upstream failed" {
+		t.Errorf("Expected trailer %q, got %q", "upstream failed", got)
+	}
+}
+
+func TestStreamSeq2ErrorBeforeFirstItem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	StreamSeq2(recorder, req, failingSeq(0, NewHTTPError(http.StatusNotFound, "no such feed")), StreamOptions{Format: StreamNDJSON})
+
+	checkResponse(t, recorder, http.StatusNotFound, `{"error":"no such feed"}`+"\n", "Content-Type", "application/json")
+}
+
+func TestStreamChan(t *testing.T) {
+	ch := make(chan string, 3)
+	ch <- "a"
+	ch <- "b"
+	close(ch)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	StreamChan(recorder, req, ch, StreamOptions{Format: StreamNDJSON, FlushEvery: 10})
+
+	checkResponse(t, recorder, http.StatusOK, "\"a\"\n\"b\"\n", "", "")
+}
+
+func TestStreamContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+
+	seq := func(yield func(int) bool) {
+		if yield(1) {
+			cancel()
+			yield(2)
+		}
+	}
+	err := StreamSeq(recorder, req, seq, StreamOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if body := recorder.Body.String(); body != "[1" {
+		t.Errorf("Expected the stream to stop silently, got body %q", body)
+	}
+}
@@ -0,0 +1,22 @@
+package gorouter
+
+import (
+	"context"
+	"net/http"
+)
+
+// UserContextKey is the context key for the authenticated user's ID.
+const UserContextKey ContextKey = "user"
+
+// WithUser returns a copy of req carrying the authenticated user's ID.
+// Authentication middleware calls it so rate limiting and logging can tell
+// users apart.
+func WithUser(req *http.Request, userID string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), UserContextKey, userID))
+}
+
+// UserFromContext returns the user ID set with WithUser, or an empty string.
+func UserFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(UserContextKey).(string)
+	return userID
+}
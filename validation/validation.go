@@ -39,14 +39,44 @@ func NewValidator() *Validator {
 	return v
 }
 
+// FieldError describes a single field that failed validation.
+type FieldError struct {
+	Field   string // Name of the struct field
+	Tag     string // Validation tag that failed, e.g. "required"
+	Param   string // Parameter of the tag, e.g. "3" for "min=3"
+	Message string // Translated, human-readable message
+}
+
+// Errors is returned by ValidateStruct when one or more fields fail validation.
+type Errors []FieldError
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	var validationErrors []string
+	for _, fe := range e {
+		validationErrors = append(validationErrors, fmt.Sprintf("field '%s' failed validation for tag '%s'", fe.Field, fe.Tag))
+	}
+	return fmt.Sprintf("validation failed: %s", validationErrors)
+}
+
 // ValidateStruct validates a struct against its defined validation rules.
+// Field failures are reported as Errors.
 func (v *Validator) ValidateStruct(s interface{}) error {
 	if err := v.validator.Struct(s); err != nil {
-		var validationErrors []string
-		for _, err := range err.(validator.ValidationErrors) {
-			validationErrors = append(validationErrors, fmt.Sprintf("field '%s' failed validation for tag '%s'", err.Field(), err.Tag()))
+		fieldErrors, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return err
+		}
+		var validationErrors Errors
+		for _, fe := range fieldErrors {
+			validationErrors = append(validationErrors, FieldError{
+				Field:   fe.Field(),
+				Tag:     fe.Tag(),
+				Param:   fe.Param(),
+				Message: fe.Translate(v.translator),
+			})
 		}
-		return fmt.Errorf("validation failed: %s", validationErrors)
+		return validationErrors
 	}
 	return nil
 }
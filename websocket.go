@@ -0,0 +1,296 @@
+package gorouter
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/saadi925/gorouter/security"
+)
+
+// DefaultWebSocketReadLimit is the message size limit used when WebSocketOptions leaves it unset.
+const DefaultWebSocketReadLimit int64 = 1 << 20 // 1 MB
+
+// websocketGUID is appended to the client key to compute Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketOptions represents configuration options for WebSocket endpoints.
+type WebSocketOptions struct {
+	CORS              *security.CORSOptions // Allowed origins; nil only allows same-origin browser requests
+	Subprotocols      []string              // Supported subprotocols in order of preference
+	ReadLimit         int64                 // Maximum message size; defaults to DefaultWebSocketReadLimit, negative disables
+	EnableCompression bool                  // Negotiate permessage-deflate when the client offers it
+}
+
+// UpgradeWebSocket performs the WebSocket handshake and takes over the
+// connection. On failure an error response is rendered and the error returned.
+func UpgradeWebSocket(w http.ResponseWriter, req *http.Request, opts WebSocketOptions) (*WebSocketConn, error) {
+	if err := checkWebSocketRequest(w, req, opts); err != nil {
+		RenderError(w, req, err)
+		return nil, err
+	}
+
+	subprotocol := ""
+	for _, supported := range opts.Subprotocols {
+		if containsToken(headerList(req.Header, "Sec-WebSocket-Protocol"), supported) {
+			subprotocol = supported
+			break
+		}
+	}
+	compress := opts.EnableCompression && acceptsDeflate(req.Header)
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		err = NewHTTPError(http.StatusInternalServerError, "").Wrap(err)
+		RenderError(w, req, err)
+		return nil, err
+	}
+	// Long-lived connections must not inherit the server's timeouts.
+	conn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(req.Header.Get("Sec-WebSocket-Key")) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	if compress {
+		response += "Sec-WebSocket-Extensions: " + deflateExtension + "\r\n"
+	}
+	if _, err := io.WriteString(conn, response+"\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	readLimit := opts.ReadLimit
+	if readLimit == 0 {
+		readLimit = DefaultWebSocketReadLimit
+	}
+	ws := newWebSocketConn(conn, brw.Reader, true, readLimit)
+	ws.jsonConfig = jsonConfigFrom(req.Context())
+	ws.subprotocol = subprotocol
+	ws.compress = compress
+	return ws, nil
+}
+
+// checkWebSocketRequest validates the handshake request.
+func checkWebSocketRequest(w http.ResponseWriter, req *http.Request, opts WebSocketOptions) error {
+	if req.Method != http.MethodGet {
+		return NewHTTPError(http.StatusMethodNotAllowed, "")
+	}
+	if !containsToken(headerList(req.Header, "Connection"), "upgrade") || !containsToken(headerList(req.Header, "Upgrade"), "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		return NewHTTPError(http.StatusUpgradeRequired, "WebSocket upgrade required")
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return NewHTTPError(http.StatusUpgradeRequired, "Unsupported WebSocket version")
+	}
+	if key, err := base64.StdEncoding.DecodeString(req.Header.Get("Sec-WebSocket-Key")); err != nil || len(key) != 16 {
+		return NewHTTPError(http.StatusBadRequest, "Invalid Sec-WebSocket-Key")
+	}
+	if !websocketOriginAllowed(req, opts.CORS) {
+		return NewHTTPError(http.StatusForbidden, "Origin not allowed")
+	}
+	return nil
+}
+
+// websocketOriginAllowed checks the Origin header. Requests without one come
+// from non-browser clients and are allowed.
+func websocketOriginAllowed(req *http.Request, cors *security.CORSOptions) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if cors != nil {
+		return cors.AllowsOrigin(origin)
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, req.Host)
+}
+
+// websocketAccept computes the Sec-WebSocket-Accept value for a client key.
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// containsToken reports whether list contains token, ignoring case.
+func containsToken(list []string, token string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebSocketHandlerFunc handles an upgraded WebSocket connection. The connection
+// is closed when it returns: normally if it returns nil, with
+// CloseInternalServerErr otherwise.
+type WebSocketHandlerFunc func(conn *WebSocketConn, req *http.Request) error
+
+// WebSocketHandler adapts a WebSocketHandlerFunc to an http.HandlerFunc.
+// Errors other than the peer closing the connection are logged.
+func WebSocketHandler(opts WebSocketOptions, handler WebSocketHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		conn, err := UpgradeWebSocket(w, req, opts)
+		if err != nil {
+			return
+		}
+
+		err = handler(conn, req)
+		var closeErr *CloseError
+		switch {
+		case err == nil:
+			conn.Close()
+		case errors.As(err, &closeErr), errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+			// The close handshake already happened or the peer is gone.
+			conn.conn.Close()
+		default:
+			logRequestError(req, err, slog.LevelError)
+			conn.CloseWithCode(CloseInternalServerErr, "")
+		}
+	}
+}
+
+// WS adds a GET route serving WebSocket connections to the route group.
+func (rg *RouteGroup) WS(path string, opts WebSocketOptions, handler WebSocketHandlerFunc, middleware ...Middleware) {
+	rg.GET(path, WebSocketHandler(opts, handler), middleware...)
+}
+
+// WebSocketDialOptions represents configuration options for DialWebSocket.
+type WebSocketDialOptions struct {
+	Header            http.Header // Additional handshake headers, such as Origin
+	Subprotocols      []string    // Requested subprotocols
+	ReadLimit         int64       // Maximum message size; defaults to DefaultWebSocketReadLimit, negative disables
+	EnableCompression bool        // Offer permessage-deflate
+	TLSConfig         *tls.Config // Used for wss:// and https:// URLs
+}
+
+// DialWebSocket opens a client WebSocket connection. It accepts ws, wss, http
+// and https URLs, so it can connect to an httptest.Server directly. The
+// handshake response is returned on success and, when available, on failure.
+func DialWebSocket(ctx context.Context, rawURL string, opts WebSocketDialOptions) (*WebSocketConn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	secure := false
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+		secure = true
+	default:
+		return nil, nil, fmt.Errorf("gorouter: unsupported websocket scheme %q", u.Scheme)
+	}
+
+	var keyBytes [16]byte
+	if _, err := rand.Read(keyBytes[:]); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, values := range opts.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(opts.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(opts.Subprotocols, ", "))
+	}
+	if opts.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; server_no_context_takeover; client_no_context_takeover")
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if secure {
+			port = "443"
+		}
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+	var conn net.Conn
+	if secure {
+		config := opts.TLSConfig.Clone()
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		conn, err = (&tls.Dialer{Config: config}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	ws, resp, err := websocketClientHandshake(conn, req, key, opts)
+	if err != nil {
+		conn.Close()
+		return nil, resp, err
+	}
+	conn.SetDeadline(time.Time{})
+	return ws, resp, nil
+}
+
+// websocketClientHandshake sends the handshake request and validates the response.
+func websocketClientHandshake(conn net.Conn, req *http.Request, key string, opts WebSocketDialOptions) (*WebSocketConn, *http.Response, error) {
+	if err := req.Write(conn); err != nil {
+		return nil, nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!containsToken(headerList(resp.Header, "Upgrade"), "websocket") ||
+		!containsToken(headerList(resp.Header, "Connection"), "upgrade") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		return nil, resp, fmt.Errorf("gorouter: websocket handshake failed with status %s", resp.Status)
+	}
+
+	readLimit := opts.ReadLimit
+	if readLimit == 0 {
+		readLimit = DefaultWebSocketReadLimit
+	}
+	ws := newWebSocketConn(conn, br, false, readLimit)
+	ws.subprotocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	for _, extension := range headerList(resp.Header, "Sec-WebSocket-Extensions") {
+		name, _, _ := strings.Cut(extension, ";")
+		if strings.TrimSpace(name) != "permessage-deflate" || !opts.EnableCompression {
+			return nil, resp, fmt.Errorf("gorouter: unexpected websocket extension %q", extension)
+		}
+		ws.compress = true
+	}
+	return ws, resp, nil
+}
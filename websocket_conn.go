@@ -0,0 +1,454 @@
+package gorouter
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WebSocket message types, as defined by RFC 6455 opcodes.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// WebSocket close codes defined by RFC 6455, section 7.4.1.
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+// frameReadChunk bounds the buffer allocated up front for a frame payload.
+const frameReadChunk = 32 << 10
+
+// ErrWebSocketClosed is returned when writing after a close frame was sent.
+var ErrWebSocketClosed = errors.New("gorouter: websocket closed")
+
+// CloseError reports a WebSocket connection closed with a close frame, sent by
+// either the peer or, after a protocol violation, this side.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Text)
+}
+
+// IsCloseError reports whether err is a *CloseError with one of the given codes.
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// WebSocketConn is a WebSocket connection. One goroutine may read while others
+// write: writes are serialized, reads are not.
+type WebSocketConn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	server      bool
+	subprotocol string
+	compress    bool
+	readLimit   int64
+	jsonConfig  *JSONConfig // Used by WriteJSON
+
+	writeMu   sync.Mutex
+	closeSent bool
+
+	pingHandler func(data []byte) error
+	pongHandler func(data []byte) error
+}
+
+// newWebSocketConn wraps an upgraded connection.
+func newWebSocketConn(conn net.Conn, br *bufio.Reader, server bool, readLimit int64) *WebSocketConn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return &WebSocketConn{conn: conn, br: br, server: server, readLimit: readLimit, jsonConfig: defaultJSONConfig}
+}
+
+// Subprotocol returns the negotiated subprotocol, if any.
+func (c *WebSocketConn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Compressed reports whether permessage-deflate was negotiated.
+func (c *WebSocketConn) Compressed() bool {
+	return c.compress
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *WebSocketConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets the maximum size of a message. Larger messages close the
+// connection with CloseMessageTooBig. Zero or less removes the limit.
+func (c *WebSocketConn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline sets the deadline for reads on the underlying connection.
+func (c *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for writes on the underlying connection.
+func (c *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetPingHandler sets the function called with the payload of received pings.
+// The default handler answers with a pong carrying the same payload.
+func (c *WebSocketConn) SetPingHandler(handler func(data []byte) error) {
+	c.pingHandler = handler
+}
+
+// SetPongHandler sets the function called with the payload of received pongs.
+func (c *WebSocketConn) SetPongHandler(handler func(data []byte) error) {
+	c.pongHandler = handler
+}
+
+// ReadMessage reads the next text or binary message, reassembling fragments
+// and answering control frames received in between. A close frame from the
+// peer is answered and returned as a *CloseError.
+func (c *WebSocketConn) ReadMessage() (messageType int, data []byte, err error) {
+	var compressed bool
+	for {
+		fin, rsv1, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType, compressed = opcode, rsv1
+		case CloseMessage:
+			return 0, nil, c.handleClose(payload)
+		case PingMessage:
+			if err := c.handlePing(payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				if err := c.pongHandler(payload); err != nil {
+					return 0, nil, err
+				}
+			}
+			continue
+		}
+
+		if c.readLimit > 0 && int64(len(data)+len(payload)) > c.readLimit {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+		data = append(data, payload...)
+		if fin {
+			break
+		}
+	}
+
+	if compressed {
+		if data, err = decompressMessage(data, c.readLimit); err != nil {
+			if errors.Is(err, errMessageTooBig) {
+				return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+			}
+			return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid compressed data")
+		}
+	}
+	if messageType == TextMessage && !utf8.Valid(data) {
+		return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid UTF-8 in text message")
+	}
+	return messageType, data, nil
+}
+
+// ReadJSON reads the next message and decodes it as JSON into v.
+func (c *WebSocketConn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage sends a message in a single frame. Text and binary messages are
+// compressed when permessage-deflate was negotiated.
+func (c *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+		if c.compress {
+			compressed, err := compressMessage(data)
+			if err != nil {
+				return err
+			}
+			return c.writeFrame(messageType, true, compressed)
+		}
+	case CloseMessage, PingMessage, PongMessage:
+		if len(data) > 125 {
+			return errors.New("gorouter: websocket control frame payload exceeds 125 bytes")
+		}
+	default:
+		return fmt.Errorf("gorouter: invalid websocket message type %d", messageType)
+	}
+	return c.writeFrame(messageType, false, data)
+}
+
+// WriteJSON sends v encoded as JSON in a text message.
+func (c *WebSocketConn) WriteJSON(v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := c.jsonConfig.encodeCompact(buf, v); err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, buf.Bytes())
+}
+
+// Ping sends a ping with the given payload.
+func (c *WebSocketConn) Ping(data []byte) error {
+	return c.WriteMessage(PingMessage, data)
+}
+
+// Close sends a normal closure frame and closes the connection.
+func (c *WebSocketConn) Close() error {
+	return c.CloseWithCode(CloseNormalClosure, "")
+}
+
+// CloseWithCode sends a close frame with the given code and reason, unless one
+// was already sent, and closes the connection.
+func (c *WebSocketConn) CloseWithCode(code int, text string) error {
+	err := c.writeFrame(CloseMessage, false, closePayload(code, text))
+	if errors.Is(err, ErrWebSocketClosed) {
+		err = nil
+	}
+	if closeErr := c.conn.Close(); err == nil && !errors.Is(closeErr, net.ErrClosed) {
+		err = closeErr
+	}
+	return err
+}
+
+// fail closes the connection after a protocol violation by the peer.
+func (c *WebSocketConn) fail(code int, text string) error {
+	c.CloseWithCode(code, text)
+	return &CloseError{Code: code, Text: text}
+}
+
+// handleClose answers a close frame and returns it as a *CloseError.
+func (c *WebSocketConn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close frame")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !validCloseCode(closeErr.Code) {
+			return c.fail(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(closeErr.Text) {
+			return c.fail(CloseInvalidFramePayloadData, "invalid UTF-8 in close reason")
+		}
+	}
+	c.CloseWithCode(closeErr.Code, "")
+	return closeErr
+}
+
+// handlePing calls the ping handler or answers with a pong.
+func (c *WebSocketConn) handlePing(payload []byte) error {
+	if c.pingHandler != nil {
+		return c.pingHandler(payload)
+	}
+	err := c.writeFrame(PongMessage, false, payload)
+	if errors.Is(err, ErrWebSocketClosed) {
+		return nil
+	}
+	return err
+}
+
+// readFrame reads and validates a single frame.
+func (c *WebSocketConn) readFrame() (fin, rsv1 bool, opcode int, payload []byte, err error) {
+	var b [8]byte
+	if _, err = io.ReadFull(c.br, b[:2]); err != nil {
+		return
+	}
+	fin = b[0]&0x80 != 0
+	rsv1 = b[0]&0x40 != 0
+	opcode = int(b[0] & 0x0f)
+	masked := b[1]&0x80 != 0
+	length := uint64(b[1] & 0x7f)
+	switch length {
+	case 126:
+		if _, err = io.ReadFull(c.br, b[:2]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err = io.ReadFull(c.br, b[:8]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(b[:8])
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+
+	control := opcode >= CloseMessage
+	switch {
+	case b[0]&0x30 != 0:
+		err = c.fail(CloseProtocolError, "reserved bits set")
+	case rsv1 && (!c.compress || control || opcode == continuationFrame):
+		err = c.fail(CloseProtocolError, "unexpected RSV1 bit")
+	case opcode > BinaryMessage && opcode < CloseMessage, opcode > PongMessage:
+		err = c.fail(CloseProtocolError, "unknown opcode")
+	case control && (!fin || length > 125):
+		err = c.fail(CloseProtocolError, "invalid control frame")
+	case masked != c.server:
+		err = c.fail(CloseProtocolError, "invalid frame masking")
+	case length>>63 != 0:
+		err = c.fail(CloseProtocolError, "invalid payload length")
+	case c.readLimit > 0 && length > uint64(c.readLimit):
+		err = c.fail(CloseMessageTooBig, "message too big")
+	}
+	if err != nil {
+		return
+	}
+
+	// Grow the payload as data arrives rather than trusting the declared
+	// length, which may be far larger than what the peer sends.
+	buf := bytes.NewBuffer(make([]byte, 0, min(length, frameReadChunk)))
+	if _, err = io.CopyN(buf, c.br, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	payload = buf.Bytes()
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return
+}
+
+// writeFrame sends a single final frame. Client frames are masked.
+func (c *WebSocketConn) writeFrame(opcode int, rsv1 bool, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrWebSocketClosed
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	first := byte(0x80 | opcode)
+	if rsv1 {
+		first |= 0x40
+	}
+	frame = append(frame, first)
+
+	var maskBit byte
+	if !c.server {
+		maskBit = 0x80
+	}
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	if c.server {
+		frame = append(frame, payload...)
+	} else {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(mask, frame[start:])
+	}
+
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// maskBytes applies the masking key to data in place.
+func maskBytes(mask [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= mask[i%4]
+	}
+}
+
+// closePayload encodes the body of a close frame.
+func closePayload(code int, text string) []byte {
+	if code == CloseNoStatusReceived {
+		return nil
+	}
+	if len(text) > 123 {
+		text = text[:123]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return append(payload, text...)
+}
+
+// validCloseCode reports whether code may be sent in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	default:
+		return false
+	}
+}
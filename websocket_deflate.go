@@ -0,0 +1,104 @@
+package gorouter
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// deflateExtension is the permessage-deflate response sent by servers. Both
+// sides reset their compression context for every message (RFC 7692).
+const deflateExtension = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
+
+var errMessageTooBig = errors.New("gorouter: websocket message too big")
+
+// deflateTail is removed from compressed messages and restored before decompression.
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+// deflateEnd is an empty final block, letting the reader see the end of the message.
+var deflateEnd = []byte{0x01, 0x00, 0x00, 0xff, 0xff}
+
+var flateWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+// compressMessage compresses a message payload for permessage-deflate.
+func compressMessage(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw := flateWriterPool.Get().(*flate.Writer)
+	defer flateWriterPool.Put(fw)
+	fw.Reset(&buf)
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+// decompressMessage decompresses a permessage-deflate payload of at most limit bytes.
+func decompressMessage(data []byte, limit int64) ([]byte, error) {
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail), bytes.NewReader(deflateEnd)))
+	defer fr.Close()
+
+	var r io.Reader = fr
+	if limit > 0 {
+		r = io.LimitReader(fr, limit+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(out)) > limit {
+		return nil, errMessageTooBig
+	}
+	return out, nil
+}
+
+// acceptsDeflate reports whether the client offered permessage-deflate with
+// parameters this implementation can honor.
+func acceptsDeflate(header http.Header) bool {
+	for _, offer := range headerList(header, "Sec-WebSocket-Extensions") {
+		params := strings.Split(offer, ";")
+		if strings.TrimSpace(params[0]) != "permessage-deflate" {
+			continue
+		}
+		ok := true
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			switch strings.TrimSpace(name) {
+			case "client_max_window_bits", "server_no_context_takeover", "client_no_context_takeover":
+			case "server_max_window_bits":
+				// The flate package always uses a 32 KB window.
+				ok = ok && strings.Trim(value, `" `) == "15"
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// headerList splits comma-separated header values into trimmed elements.
+func headerList(header http.Header, name string) []string {
+	var list []string
+	for _, value := range header.Values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
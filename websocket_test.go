@@ -0,0 +1,280 @@
+package gorouter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saadi925/gorouter/security"
+)
+
+// newWebSocketServer serves an echo WebSocket endpoint at /ws.
+func newWebSocketServer(t *testing.T, opts WebSocketOptions) *httptest.Server {
+	t.Helper()
+	router := NewRouter()
+	router.Group("").WS("/ws", opts, func(conn *WebSocketConn, req *http.Request) error {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return err
+			}
+		}
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialTest connects to the /ws endpoint of server.
+func dialTest(t *testing.T, server *httptest.Server, opts WebSocketDialOptions) *WebSocketConn {
+	t.Helper()
+	return dialTestURL(t, server.URL+"/ws", opts)
+}
+
+// dialTestPath connects to path on server with default options.
+func dialTestPath(t *testing.T, server *httptest.Server, path string) *WebSocketConn {
+	t.Helper()
+	return dialTestURL(t, server.URL+path, WebSocketDialOptions{})
+}
+
+func dialTestURL(t *testing.T, url string, opts WebSocketDialOptions) *WebSocketConn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, _, err := DialWebSocket(ctx, url, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{})
+	conn := dialTest(t, server, WebSocketDialOptions{})
+
+	conn.WriteMessage(TextMessage, []byte("hello"))
+	messageType, data, err := conn.ReadMessage()
+	if err != nil || messageType != TextMessage || string(data) != "hello" {
+		t.Errorf("Expected text echo, got %d %q %v", messageType, data, err)
+	}
+
+	large := bytes.Repeat([]byte{7}, 70000)
+	conn.WriteMessage(BinaryMessage, large)
+	messageType, data, err = conn.ReadMessage()
+	if err != nil || messageType != BinaryMessage || !bytes.Equal(data, large) {
+		t.Errorf("Expected binary echo of %d bytes, got %d %d bytes %v", len(large), messageType, len(data), err)
+	}
+
+	conn.WriteJSON(map[string]int{"n": 1})
+	var got map[string]int
+	if err := conn.ReadJSON(&got); err != nil || got["n"] != 1 {
+		t.Errorf("Expected JSON echo, got %v %v", got, err)
+	}
+}
+
+func TestWebSocketCompression(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{EnableCompression: true})
+	conn := dialTest(t, server, WebSocketDialOptions{EnableCompression: true})
+	if !conn.Compressed() {
+		t.Fatal("Expected permessage-deflate to be negotiated")
+	}
+
+	message := strings.Repeat("compress me ", 1000)
+	for i := 0; i < 2; i++ {
+		conn.WriteMessage(TextMessage, []byte(message))
+		_, data, err := conn.ReadMessage()
+		if err != nil || string(data) != message {
+			t.Fatalf("Expected compressed echo, got %d bytes %v", len(data), err)
+		}
+	}
+}
+
+func TestWebSocketSubprotocol(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{Subprotocols: []string{"v2", "v1"}})
+	conn := dialTest(t, server, WebSocketDialOptions{Subprotocols: []string{"v1", "v2"}})
+	if conn.Subprotocol() != "v2" {
+		t.Errorf("Expected subprotocol v2, got %q", conn.Subprotocol())
+	}
+}
+
+func TestWebSocketOriginCheck(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{CORS: &security.CORSOptions{AllowedOrigins: []string{"https://app.example"}}})
+
+	_, resp, err := DialWebSocket(context.Background(), server.URL+"/ws", WebSocketDialOptions{
+		Header: http.Header{"Origin": {"https://evil.example"}},
+	})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a disallowed origin, got %v %v", resp, err)
+	}
+
+	dialTest(t, server, WebSocketDialOptions{Header: http.Header{"Origin": {"https://app.example"}}})
+}
+
+func TestWebSocketSameOriginDefault(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{})
+	_, resp, err := DialWebSocket(context.Background(), server.URL+"/ws", WebSocketDialOptions{
+		Header: http.Header{"Origin": {"https://evil.example"}},
+	})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-origin request, got %v %v", resp, err)
+	}
+	dialTest(t, server, WebSocketDialOptions{Header: http.Header{"Origin": {server.URL}}})
+}
+
+func TestWebSocketUpgradeRequired(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{})
+	resp, err := http.Get(server.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired || resp.Header.Get("Upgrade") != "websocket" {
+		t.Errorf("Expected 426 with Upgrade header, got %d %q", resp.StatusCode, resp.Header.Get("Upgrade"))
+	}
+}
+
+func TestWebSocketReadLimit(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{ReadLimit: 10})
+	conn := dialTest(t, server, WebSocketDialOptions{})
+
+	conn.WriteMessage(TextMessage, []byte("more than ten bytes"))
+	if _, _, err := conn.ReadMessage(); !IsCloseError(err, CloseMessageTooBig) {
+		t.Errorf("Expected close %d, got %v", CloseMessageTooBig, err)
+	}
+}
+
+func TestWebSocketDeclaredLength(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{})
+	if conn := dialTest(t, server, WebSocketDialOptions{}); conn.readLimit != DefaultWebSocketReadLimit {
+		t.Errorf("Expected clients to default to a %d byte limit, got %d", DefaultWebSocketReadLimit, conn.readLimit)
+	}
+
+	// A frame header declaring 1 TiB, followed by a few bytes and EOF.
+	header := []byte{0x82, 127, 0, 0, 1, 0, 0, 0, 0, 0}
+
+	for _, readLimit := range []int64{DefaultWebSocketReadLimit, -1} {
+		client, peer := net.Pipe()
+		go func() {
+			peer.Write(append(header, "abc"...))
+			peer.Close()
+		}()
+		conn := newWebSocketConn(client, bufio.NewReader(client), false, readLimit)
+		_, _, err := conn.ReadMessage()
+		if readLimit > 0 && !IsCloseError(err, CloseMessageTooBig) {
+			t.Errorf("Expected close %d, got %v", CloseMessageTooBig, err)
+		}
+		if readLimit < 0 && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Expected an unexpected EOF, got %v", err)
+		}
+		client.Close()
+	}
+}
+
+// writeRawFrame sends a masked client frame without validation.
+func writeRawFrame(t *testing.T, conn *WebSocketConn, first byte, payload []byte, masked bool) {
+	t.Helper()
+	frame := []byte{first, byte(len(payload))}
+	if masked {
+		mask := [4]byte{1, 2, 3, 4}
+		frame[1] |= 0x80
+		frame = append(frame, mask[:]...)
+		body := append([]byte(nil), payload...)
+		maskBytes(mask, body)
+		frame = append(frame, body...)
+	} else {
+		frame = append(frame, payload...)
+	}
+	if _, err := conn.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebSocketFragmentationAndPing(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{})
+	conn := dialTest(t, server, WebSocketDialOptions{})
+
+	var pong string
+	conn.SetPongHandler(func(data []byte) error {
+		pong = string(data)
+		return nil
+	})
+	writeRawFrame(t, conn, TextMessage, []byte("Hel"), true)
+	writeRawFrame(t, conn, 0x80|PingMessage, []byte("ping"), true)
+	writeRawFrame(t, conn, 0x80|continuationFrame, []byte("lo"), true)
+
+	_, data, err := conn.ReadMessage()
+	if err != nil || string(data) != "Hello" {
+		t.Errorf("Expected reassembled message, got %q %v", data, err)
+	}
+	if pong != "ping" {
+		t.Errorf("Expected pong with ping payload, got %q", pong)
+	}
+}
+
+func TestWebSocketProtocolError(t *testing.T) {
+	server := newWebSocketServer(t, WebSocketOptions{})
+
+	tests := []struct {
+		name    string
+		first   byte
+		payload []byte
+		masked  bool
+		code    int
+	}{
+		{"unmasked frame", 0x80 | TextMessage, []byte("x"), false, CloseProtocolError},
+		{"unknown opcode", 0x80 | 3, nil, true, CloseProtocolError},
+		{"unexpected continuation", 0x80 | continuationFrame, []byte("x"), true, CloseProtocolError},
+		{"invalid UTF-8", 0x80 | TextMessage, []byte{0xff, 0xfe}, true, CloseInvalidFramePayloadData},
+		{"invalid close code", 0x80 | CloseMessage, binary.BigEndian.AppendUint16(nil, 1005), true, CloseProtocolError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dialTest(t, server, WebSocketDialOptions{})
+			writeRawFrame(t, conn, tt.first, tt.payload, tt.masked)
+			if _, _, err := conn.ReadMessage(); !IsCloseError(err, tt.code) {
+				t.Errorf("Expected close %d, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestWebSocketCloseHandshake(t *testing.T) {
+	closed := make(chan error, 1)
+	router := NewRouter()
+	router.Group("").WS("/ws", WebSocketOptions{}, func(conn *WebSocketConn, req *http.Request) error {
+		_, _, err := conn.ReadMessage()
+		closed <- err
+		return err
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn := dialTest(t, server, WebSocketDialOptions{})
+	conn.writeFrame(CloseMessage, false, closePayload(CloseGoingAway, "bye"))
+
+	if _, _, err := conn.ReadMessage(); !IsCloseError(err, CloseGoingAway) {
+		t.Errorf("Expected the server to echo close %d, got %v", CloseGoingAway, err)
+	}
+	select {
+	case err := <-closed:
+		if !IsCloseError(err, CloseGoingAway) {
+			t.Errorf("Expected handler to see close %d, got %v", CloseGoingAway, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Handler did not return")
+	}
+}